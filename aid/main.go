@@ -0,0 +1,348 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: main.go
+ * @Package: main
+ * @Version: 1.0.0
+ * @Date: 2026/10/19 09:10
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/zooyer/android/user"
+)
+
+func help() {
+	fmt.Println("usage: aid [-json] [-root DIR] [-api LEVEL] COMMAND [ARG...]")
+	fmt.Println()
+	fmt.Println("Look up the Android users, groups and ids.")
+	fmt.Println()
+	fmt.Println("commands:")
+	fmt.Println("  passwd [NAME|UID...]   print the passwd entries, all of them by default")
+	fmt.Println("  group [NAME|GID...]    print the group entries, all of them by default")
+	fmt.Println("  id [USER]              print the uid, gid and groups of USER (default current)")
+	fmt.Println("  ranges                 print the reserved app and OEM id ranges")
+	fmt.Println("  fsconfig PATH...       print the fs_config of PATH, a trailing / for directories")
+	fmt.Println()
+	fmt.Println("Exit status is 2 if an entry is not found.")
+	fmt.Println()
+}
+
+var jsonOutput bool
+
+// The output of the commands.
+var stdout io.Writer = os.Stdout
+
+func errorExit(status int, msg string) {
+	_, _ = fmt.Fprintln(os.Stderr, msg)
+	os.Exit(status)
+}
+
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		errorExit(1, err.Error())
+	}
+
+	_, _ = fmt.Fprintln(stdout, string(data))
+}
+
+// parseID returns the id of key, ok is false if key is a name.
+func parseID(key string) (id uint32, ok bool) {
+	num, err := strconv.ParseUint(key, 10, 32)
+	if err != nil {
+		return
+	}
+
+	return uint32(num), true
+}
+
+func lookupPasswd(key string) *user.Passwd {
+	if id, ok := parseID(key); ok {
+		return user.Getpwuid(id)
+	}
+
+	return user.Getpwnam(key)
+}
+
+func lookupGroup(key string) *user.Group {
+	if id, ok := parseID(key); ok {
+		return user.Getgrgid(id)
+	}
+
+	return user.Getgrnam(key)
+}
+
+func formatPasswd(pw *user.Passwd) string {
+	return fmt.Sprintf("%s:x:%d:%d::%s:%s", pw.Name, pw.UID, pw.GID, pw.Dir, pw.Shell)
+}
+
+func formatGroup(group *user.Group) string {
+	return fmt.Sprintf("%s:x:%d:%s", group.Name, group.GID, strings.Join(group.Members, ","))
+}
+
+func passwd(args []string) (status int) {
+	var list []*user.Passwd
+	if len(args) == 0 {
+		user.Passwds(func(pw *user.Passwd) bool {
+			list = append(list, pw)
+			return true
+		})
+	}
+
+	for _, key := range args {
+		if pw := lookupPasswd(key); pw != nil {
+			list = append(list, pw)
+		} else {
+			status = 2
+		}
+	}
+
+	if jsonOutput {
+		printJSON(list)
+		return
+	}
+
+	for _, pw := range list {
+		_, _ = fmt.Fprintln(stdout, formatPasswd(pw))
+	}
+
+	return
+}
+
+func group(args []string) (status int) {
+	var list []*user.Group
+	if len(args) == 0 {
+		user.Groups(func(group *user.Group) bool {
+			list = append(list, group)
+			return true
+		})
+	}
+
+	for _, key := range args {
+		if group := lookupGroup(key); group != nil {
+			list = append(list, group)
+		} else {
+			status = 2
+		}
+	}
+
+	if jsonOutput {
+		printJSON(list)
+		return
+	}
+
+	for _, group := range list {
+		_, _ = fmt.Fprintln(stdout, formatGroup(group))
+	}
+
+	return
+}
+
+type idName struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+func (n idName) String() string {
+	if n.Name == "" {
+		return strconv.FormatUint(uint64(n.ID), 10)
+	}
+
+	return fmt.Sprintf("%d(%s)", n.ID, n.Name)
+}
+
+func uidName(uid uint32) idName {
+	if pw := user.Getpwuid(uid); pw != nil {
+		return idName{uid, pw.Name}
+	}
+
+	return idName{ID: uid}
+}
+
+func gidName(gid uint32) idName {
+	if group := user.Getgrgid(gid); group != nil {
+		return idName{gid, group.Name}
+	}
+
+	return idName{ID: gid}
+}
+
+func id(args []string) (status int) {
+	var (
+		uid, gid uint32
+		groups   []uint32
+	)
+
+	switch len(args) {
+	case 0:
+		uid, gid = uint32(os.Getuid()), uint32(os.Getgid())
+		list, err := os.Getgroups()
+		if err != nil {
+			errorExit(1, err.Error())
+		}
+		for _, group := range list {
+			groups = append(groups, uint32(group))
+		}
+	case 1:
+		var pw = lookupPasswd(args[0])
+		if pw == nil {
+			_, _ = fmt.Fprintf(os.Stderr, "id: no such user '%s'\n", args[0])
+			return 2
+		}
+		uid, gid = pw.UID, pw.GID
+
+		var list = make([]uint32, 32)
+		ngroups, err := user.GetGroupList(pw.Name, pw.GID, list)
+		if err != nil {
+			list = make([]uint32, ngroups)
+			ngroups, _ = user.GetGroupList(pw.Name, pw.GID, list)
+		}
+		groups = list[:ngroups]
+	default:
+		help()
+		return 1
+	}
+
+	var result = struct {
+		UID    idName   `json:"uid"`
+		GID    idName   `json:"gid"`
+		Groups []idName `json:"groups"`
+	}{UID: uidName(uid), GID: gidName(gid)}
+	for _, group := range groups {
+		result.Groups = append(result.Groups, gidName(group))
+	}
+
+	if jsonOutput {
+		printJSON(result)
+		return
+	}
+
+	// The toybox id format: uid=0(root) gid=0(root) groups=0(root),1004(input)
+	var line = fmt.Sprintf("uid=%s gid=%s", result.UID, result.GID)
+	if len(result.Groups) > 0 {
+		var names = make([]string, 0, len(result.Groups))
+		for _, group := range result.Groups {
+			names = append(names, group.String())
+		}
+		line += " groups=" + strings.Join(names, ",")
+	}
+	_, _ = fmt.Fprintln(stdout, line)
+
+	return
+}
+
+func ranges(args []string) (status int) {
+	if len(args) != 0 {
+		help()
+		return 1
+	}
+
+	var result = struct {
+		User  []user.IDRange `json:"user"`
+		Group []user.IDRange `json:"group"`
+		Oem   []user.IDRange `json:"oem"`
+	}{user.UserRanges(), user.GroupRanges(), user.OemRanges()}
+
+	if jsonOutput {
+		printJSON(result)
+		return
+	}
+
+	var printRanges = func(kind string, list []user.IDRange) {
+		for _, r := range list {
+			_, _ = fmt.Fprintf(stdout, "%s %d-%d\n", kind, r.Start, r.End)
+		}
+	}
+	printRanges("user", result.User)
+	printRanges("group", result.Group)
+	printRanges("oem", result.Oem)
+
+	return
+}
+
+func fsconfig(args []string) (status int) {
+	if len(args) == 0 {
+		help()
+		return 1
+	}
+
+	type entry struct {
+		Path string `json:"path"`
+		Dir  bool   `json:"dir"`
+		user.FSPathConfig
+	}
+
+	var list []entry
+	for _, path := range args {
+		var dir = strings.HasSuffix(path, "/")
+		list = append(list, entry{path, dir, user.FSConfig(strings.TrimSuffix(path, "/"), dir, "")})
+	}
+
+	if jsonOutput {
+		printJSON(list)
+		return
+	}
+
+	for _, e := range list {
+		var line = fmt.Sprintf("%s %s %04o %s %s", e.Path, strconv.Quote(e.Prefix), e.Mode, uidName(uint32(e.UID)), gidName(uint32(e.GID)))
+		if e.Capabilities != 0 {
+			line += " " + user.FormatCapabilities(e.Capabilities)
+		}
+		_, _ = fmt.Fprintln(stdout, line)
+	}
+
+	return
+}
+
+// run runs the aid command line args and returns the exit status.
+func run(args []string) int {
+	var flags = flag.NewFlagSet("aid", flag.ExitOnError)
+	var (
+		root = flags.String("root", "/", "resolve the partition files below `DIR`")
+		api  = flags.Int("api", 0, "look up the ids of the Android API `LEVEL`, 0 for the newest")
+	)
+	flags.BoolVar(&jsonOutput, "json", false, "print the output as JSON")
+	flags.Usage = help
+	_ = flags.Parse(args)
+
+	user.RootDir = *root
+	if *api != 0 {
+		user.SetProfile(user.Profile{API: *api})
+	}
+
+	if args = flags.Args(); len(args) == 0 {
+		help()
+		return 1
+	}
+
+	var commands = map[string]func(args []string) int{
+		"passwd":   passwd,
+		"group":    group,
+		"id":       id,
+		"ranges":   ranges,
+		"fsconfig": fsconfig,
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		_, _ = fmt.Fprintf(os.Stderr, "aid: unknown command '%s'\n", args[0])
+		return 1
+	}
+
+	return command(args[1:])
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/zooyer/android/user"
+)
+
+// runAid runs the aid command line args against user/testdata and returns
+// the exit status and the output.
+func runAid(t *testing.T, args ...string) (int, string) {
+	var oldRoot, oldProfile, oldStdout = user.RootDir, user.CurrentProfile(), stdout
+	t.Cleanup(func() {
+		user.RootDir, stdout = oldRoot, oldStdout
+		user.SetProfile(oldProfile)
+	})
+
+	var output bytes.Buffer
+	stdout = &output
+	var status = run(append([]string{"-root", "../user/testdata/root"}, args...))
+
+	return status, output.String()
+}
+
+func TestCommands(t *testing.T) {
+	var tests = []struct {
+		args   []string
+		status int
+		output string
+	}{
+		{[]string{"passwd", "root", "2000", "vendor_rfs", "u10_a48"}, 0, `root:x:0:0::/:/bin/sh
+shell:x:2000:2000::/:/bin/sh
+vendor_rfs:x:2951:2951::/data/vendor/rfs:/vendor/bin/sh
+u10_a48:x:1010048:1010048::/data:/bin/sh
+`},
+		{[]string{"-json", "passwd", "system", "u0_i5"}, 0, `[
+  {
+    "name": "system",
+    "uid": 1000,
+    "gid": 1000,
+    "dir": "/",
+    "shell": "/bin/sh"
+  },
+  {
+    "name": "u0_i5",
+    "uid": 90005,
+    "gid": 90005,
+    "dir": "/data",
+    "shell": "/bin/sh"
+  }
+]
+`},
+		{[]string{"passwd", "root", "nobody_here"}, 2, "root:x:0:0::/:/bin/sh\n"},
+		{[]string{"group", "vendor_qtidataservices", "3003"}, 0, `vendor_qtidataservices:x:2903:vendor_qtidataservices,vendor_rfs
+inet:x:3003:
+`},
+		{[]string{"-json", "group", "inet"}, 0, `[
+  {
+    "name": "inet",
+    "gid": 3003,
+    "members": null
+  }
+]
+`},
+		{[]string{"group", "nobody_here"}, 2, ""},
+		{[]string{"id", "shell"}, 0, "uid=2000(shell) gid=2000(shell) groups=2000(shell)\n"},
+		{[]string{"-json", "id", "vendor_rfs"}, 0, `{
+  "uid": {
+    "id": 2951,
+    "name": "vendor_rfs"
+  },
+  "gid": {
+    "id": 2951,
+    "name": "vendor_rfs"
+  },
+  "groups": [
+    {
+      "id": 2951,
+      "name": "vendor_rfs"
+    },
+    {
+      "id": 2903,
+      "name": "vendor_qtidataservices"
+    }
+  ]
+}
+`},
+		{[]string{"id", "nobody_here"}, 2, ""},
+		{[]string{"-api", "29", "ranges"}, 0, `user 10000-19999
+user 90000-99999
+group 10000-19999
+group 20000-29999
+group 30000-39999
+group 40000-49999
+group 50000-59999
+group 90000-99999
+oem 2900-2999
+oem 5000-5999
+`},
+		{[]string{"-api", "21", "-json", "ranges"}, 0, `{
+  "user": [
+    {
+      "start": 10000,
+      "end": 19999
+    },
+    {
+      "start": 99000,
+      "end": 99999
+    }
+  ],
+  "group": [
+    {
+      "start": 10000,
+      "end": 19999
+    },
+    {
+      "start": 50000,
+      "end": 59999
+    },
+    {
+      "start": 99000,
+      "end": 99999
+    }
+  ],
+  "oem": [
+    {
+      "start": 2900,
+      "end": 2999
+    }
+  ]
+}
+`},
+		{[]string{"fsconfig", "/system/bin/sh", "/system/xbin/su", "/data/"}, 0, `/system/bin/sh "system/bin/*" 0755 0(root) 2000(shell)
+/system/xbin/su "system/xbin/su" 4750 0(root) 2000(shell)
+/data/ "data" 0771 1000(system) 1000(system)
+`},
+		{[]string{"-json", "fsconfig", "/system/bin/run-as"}, 0, `[
+  {
+    "path": "/system/bin/run-as",
+    "dir": false,
+    "mode": 488,
+    "uid": 0,
+    "gid": 2000,
+    "capabilities": 192,
+    "prefix": "system/bin/run-as"
+  }
+]
+`},
+		{[]string{"unknown"}, 1, ""},
+	}
+
+	for _, test := range tests {
+		status, output := runAid(t, test.args...)
+		if status != test.status || output != test.output {
+			t.Fatalf("aid %s = %d\n%s\nwant %d\n%s", strings.Join(test.args, " "), status, output, test.status, test.output)
+		}
+	}
+}
+
+func TestCommandsAll(t *testing.T) {
+	status, output := runAid(t, "passwd")
+	if status != 0 || !strings.HasPrefix(output, "root:x:0:0::/:/bin/sh\n") || !strings.Contains(output, "\nvendor_rfs:x:2951:") {
+		t.Fatalf("aid passwd = %d\n%.200s", status, output)
+	}
+
+	status, output = runAid(t, "group")
+	if status != 0 || !strings.HasPrefix(output, "root:x:0:\n") || !strings.Contains(output, "\nvendor_qtidataservices:x:2903:") {
+		t.Fatalf("aid group = %d\n%.200s", status, output)
+	}
+}
@@ -0,0 +1,408 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: area.go
+ * @Package: property
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 19:20
+ */
+
+package property
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// The layout of a bionic prop_area, the memory mapped files below
+// /dev/__properties__ holding the runtime properties:
+//
+//	struct prop_area {
+//	    uint32_t bytes_used_;
+//	    atomic_uint_least32_t serial_;
+//	    uint32_t magic_;
+//	    uint32_t version_;
+//	    uint32_t reserved_[28];
+//	    char data_[0];
+//	};
+//
+// data_ holds a trie of prop_bt nodes, one per '.' separated segment of the
+// names, each level kept as a binary tree. The offsets are relative to data_.
+const (
+	propAreaMagic      = 0x504f5250
+	propAreaVersion    = 0xfc6ed0ab
+	propAreaHeaderSize = 128
+
+	// struct prop_bt { uint32_t namelen, prop, left, right, children; char name[0]; }
+	propBtNameOffset = 20
+
+	// struct prop_info { uint32_t serial; char value[PROP_VALUE_MAX]; char name[0]; }
+	// Long values keep an error message in value, followed by the offset of
+	// the real value relative to the prop_info.
+	propValueMax         = 92
+	propInfoNameOffset   = 4 + propValueMax
+	propInfoLongOffset   = 4 + 56
+	propInfoLongFlag     = 1 << 16
+	propInfoValueLenBits = 24
+)
+
+var (
+	ErrNotArea       = errors.New("not a property area")
+	ErrAreaCorrupted = errors.New("property area is corrupted")
+)
+
+// Info is a property found in a property area.
+type Info struct {
+	Name   string
+	Value  string
+	Serial uint32
+}
+
+// Area is a decoded bionic prop_area.
+type Area struct {
+	Serial uint32 // serial of the area, bumped on every change
+	data   []byte // data_ of the area
+}
+
+// ParseArea decodes the prop_area image data.
+func ParseArea(data []byte) (area *Area, err error) {
+	if len(data) < propAreaHeaderSize {
+		return nil, ErrNotArea
+	}
+
+	var order = binary.LittleEndian
+	if order.Uint32(data[8:]) != propAreaMagic || order.Uint32(data[12:]) != propAreaVersion {
+		return nil, ErrNotArea
+	}
+
+	var used = order.Uint32(data[0:])
+	if uint64(used) > uint64(len(data)-propAreaHeaderSize) || used < propBtNameOffset {
+		return nil, ErrAreaCorrupted
+	}
+
+	return &Area{
+		Serial: order.Uint32(data[4:]),
+		data:   data[propAreaHeaderSize : propAreaHeaderSize+int(used)],
+	}, nil
+}
+
+// ReadArea reads and decodes the prop_area file path.
+func ReadArea(path string) (area *Area, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	return ParseArea(data)
+}
+
+func (a *Area) uint32(off uint32) (uint32, error) {
+	if uint64(off)+4 > uint64(len(a.data)) {
+		return 0, ErrAreaCorrupted
+	}
+
+	return binary.LittleEndian.Uint32(a.data[off:]), nil
+}
+
+// cstring returns the NUL terminated string at off.
+func (a *Area) cstring(off uint32) (string, error) {
+	if uint64(off) >= uint64(len(a.data)) {
+		return "", ErrAreaCorrupted
+	}
+
+	var end = bytes.IndexByte(a.data[off:], 0)
+	if end < 0 {
+		return "", ErrAreaCorrupted
+	}
+
+	return string(a.data[off : off+uint32(end)]), nil
+}
+
+// node returns the name of the prop_bt at off.
+func (a *Area) node(off uint32) (name string, err error) {
+	size, err := a.uint32(off)
+	if err != nil {
+		return
+	}
+
+	var start = uint64(off) + propBtNameOffset
+	if start+uint64(size) > uint64(len(a.data)) {
+		return "", ErrAreaCorrupted
+	}
+
+	return string(a.data[start : start+uint64(size)]), nil
+}
+
+// field returns the field index of the prop_bt at off: 1 prop, 2 left,
+// 3 right and 4 children.
+func (a *Area) field(off uint32, index uint32) (uint32, error) {
+	return a.uint32(off + index*4)
+}
+
+func (a *Area) info(off uint32) (info Info, err error) {
+	if info.Serial, err = a.uint32(off); err != nil {
+		return
+	}
+
+	if info.Name, err = a.cstring(off + propInfoNameOffset); err != nil {
+		return
+	}
+
+	if info.Serial&propInfoLongFlag != 0 {
+		long, err := a.uint32(off + propInfoLongOffset)
+		if err != nil {
+			return info, err
+		}
+		info.Value, err = a.cstring(off + long)
+		return info, err
+	}
+
+	var size = info.Serial >> propInfoValueLenBits
+	if size >= propValueMax {
+		return info, ErrAreaCorrupted
+	}
+	info.Value = string(a.data[off+4 : off+4+size])
+
+	return
+}
+
+// cmpPropName orders names by length first, as bionic does.
+func cmpPropName(one, two string) int {
+	switch {
+	case len(one) < len(two):
+		return -1
+	case len(one) > len(two):
+		return 1
+	case one < two:
+		return -1
+	case one > two:
+		return 1
+	}
+
+	return 0
+}
+
+// Find looks up the property name in the area.
+func (a *Area) Find(name string) (info Info, ok bool) {
+	var (
+		current   uint32 // the root node
+		remaining = name
+		more      = true
+	)
+
+	for more {
+		var segment string
+		if segment, remaining, more = strings.Cut(remaining, "."); segment == "" {
+			return
+		}
+
+		// Walk the binary tree of the children for the segment.
+		next, err := a.field(current, 4)
+		for err == nil && next != 0 {
+			var nodeName string
+			if nodeName, err = a.node(next); err != nil {
+				return
+			}
+
+			var cmp = cmpPropName(segment, nodeName)
+			if cmp == 0 {
+				break
+			}
+			if cmp < 0 {
+				next, err = a.field(next, 2)
+			} else {
+				next, err = a.field(next, 3)
+			}
+		}
+		if err != nil || next == 0 {
+			return
+		}
+
+		current = next
+	}
+
+	prop, err := a.field(current, 1)
+	if err != nil || prop == 0 {
+		return
+	}
+
+	if info, err = a.info(prop); err != nil {
+		return
+	}
+
+	return info, true
+}
+
+// Foreach calls fn for every property of the area, in trie order.
+func (a *Area) Foreach(fn func(info Info)) (err error) {
+	var visited = make(map[uint32]bool)
+
+	var walk func(off uint32) error
+	walk = func(off uint32) (err error) {
+		// A node reached twice means the trie loops.
+		if visited[off] {
+			return ErrAreaCorrupted
+		}
+		visited[off] = true
+
+		var fields [5]uint32
+		for i := range fields {
+			if fields[i], err = a.field(off, uint32(i)); err != nil {
+				return
+			}
+		}
+
+		if fields[2] != 0 {
+			if err = walk(fields[2]); err != nil {
+				return
+			}
+		}
+
+		if fields[1] != 0 {
+			info, err := a.info(fields[1])
+			if err != nil {
+				return err
+			}
+			fn(info)
+		}
+
+		if fields[4] != 0 {
+			if err = walk(fields[4]); err != nil {
+				return
+			}
+		}
+
+		if fields[3] != 0 {
+			if err = walk(fields[3]); err != nil {
+				return
+			}
+		}
+
+		return
+	}
+
+	return walk(0)
+}
+
+// Areas is the set of property areas below a /dev/__properties__ path. The
+// areas are read when first needed, and kept, so reopen the Areas to see
+// changed values. It is safe for concurrent use.
+type Areas struct {
+	path   string
+	single bool          // path is a single area file, before Android O
+	info   *PropertyInfo // nil before Android P
+
+	mutex sync.Mutex
+	areas map[string]*Area // by file name, nil if unreadable
+}
+
+// OpenAreas opens the property areas at path. Since Android O path is a
+// directory of one area file per SELinux context, which are looked up
+// through its property_info since Android P; it's a single area file on
+// older releases.
+func OpenAreas(path string) (areas *Areas, err error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	areas = &Areas{path: path, areas: make(map[string]*Area)}
+	if !stat.IsDir() {
+		var area *Area
+		if area, err = ReadArea(path); err != nil {
+			return nil, err
+		}
+		areas.single, areas.areas[""] = true, area
+		return
+	}
+
+	if areas.info, err = ReadPropertyInfo(filepath.Join(path, "property_info")); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		areas.info, err = nil, nil
+	}
+
+	return
+}
+
+// area returns the area file name, nil if it can't be read.
+func (a *Areas) area(name string) *Area {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	area, ok := a.areas[name]
+	if !ok {
+		area, _ = ReadArea(filepath.Join(a.path, name))
+		a.areas[name] = area
+	}
+
+	return area
+}
+
+// files returns the names of the area files holding properties.
+func (a *Areas) files() (names []string) {
+	if a.single {
+		return []string{""}
+	}
+
+	if a.info != nil {
+		return a.info.Contexts()
+	}
+
+	entries, _ := os.ReadDir(a.path)
+	for _, entry := range entries {
+		switch name := entry.Name(); name {
+		case "property_info", "properties_serial":
+		default:
+			if entry.Type().IsRegular() {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return
+}
+
+// Find looks up the property name in the areas.
+func (a *Areas) Find(name string) (info Info, ok bool) {
+	if a.info != nil {
+		if context, _ := a.info.Lookup(name); context != "" {
+			if area := a.area(context); area != nil {
+				return area.Find(name)
+			}
+		}
+		return
+	}
+
+	// Without property_info any area may hold the property.
+	for _, file := range a.files() {
+		if area := a.area(file); area != nil {
+			if info, ok = area.Find(name); ok {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// Properties returns all the properties of the areas which can be read.
+func (a *Areas) Properties() Properties {
+	var props = make(Properties)
+	for _, file := range a.files() {
+		if area := a.area(file); area != nil {
+			_ = area.Foreach(func(info Info) {
+				props[info.Name] = info.Value
+			})
+		}
+	}
+
+	return props
+}
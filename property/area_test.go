@@ -0,0 +1,228 @@
+package property
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withAreas copies the captured areas of testdata/__properties__ below
+// dir/dev/__properties__, under the context names they have on a device.
+func withAreas(t *testing.T, dir string) string {
+	var path = filepath.Join(dir, "dev/__properties__")
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir("testdata/__properties__")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join("testdata/__properties__", entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var name = entry.Name()
+		if strings.HasSuffix(name, "_prop") {
+			name = "u:object_r:" + name + ":s0"
+		}
+		if err = os.WriteFile(filepath.Join(path, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return path
+}
+
+func TestArea(t *testing.T) {
+	area, err := ReadArea("testdata/__properties__/build_prop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, ok := area.Find("ro.build.version.sdk")
+	if !ok || info.Name != "ro.build.version.sdk" || info.Value != "29" {
+		t.Fatalf("Find(ro.build.version.sdk) = %+v, %v", info, ok)
+	}
+
+	// A long property keeps its value out of the prop_info.
+	info, ok = area.Find("ro.build.fingerprint")
+	if !ok || len(info.Value) < propValueMax || !strings.HasPrefix(info.Value, "CMDC/CM311-1a/") {
+		t.Fatalf("Find(ro.build.fingerprint) = %+v, %v", info, ok)
+	}
+
+	for _, name := range []string{"ro.build", "ro.build.version", "ro.build.version.sdk.", "", "ro..id", "sys.boot_completed"} {
+		if info, ok = area.Find(name); ok {
+			t.Fatalf("Find(%q) = %+v", name, info)
+		}
+	}
+
+	var names []string
+	if err = area.Foreach(func(info Info) { names = append(names, info.Name) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 4 {
+		t.Fatalf("Foreach = %v", names)
+	}
+
+	serial, err := ReadArea("testdata/__properties__/properties_serial")
+	if err != nil || serial.Serial != 42 {
+		t.Fatalf("properties_serial = %+v, %v", serial, err)
+	}
+}
+
+func TestParseAreaDamaged(t *testing.T) {
+	data, err := os.ReadFile("testdata/__properties__/system_prop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = ParseArea(data[:100]); !errors.Is(err, ErrNotArea) {
+		t.Fatalf("short area: %v", err)
+	}
+	if _, err = ParseArea(data[:len(data)-8]); !errors.Is(err, ErrAreaCorrupted) {
+		t.Fatalf("truncated area: %v", err)
+	}
+
+	var bad = append([]byte(nil), data...)
+	bad[8]++
+	if _, err = ParseArea(bad); !errors.Is(err, ErrNotArea) {
+		t.Fatalf("bad magic: %v", err)
+	}
+
+	// The first child of the root pointing back to itself.
+	var loop = append([]byte(nil), data...)
+	var child = binary.LittleEndian.Uint32(loop[propAreaHeaderSize+16:])
+	binary.LittleEndian.PutUint32(loop[propAreaHeaderSize+child+8:], child)
+	area, err := ParseArea(loop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = area.Foreach(func(Info) {}); !errors.Is(err, ErrAreaCorrupted) {
+		t.Fatalf("looping area: %v", err)
+	}
+}
+
+func TestPropertyInfo(t *testing.T) {
+	info, err := ReadPropertyInfo("testdata/__properties__/property_info")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name    string
+		context string
+		typ     string
+	}{
+		{"ro.build.version.sdk", "u:object_r:build_prop:s0", "string"},
+		{"ro.product.first_api_level", "u:object_r:build_prop:s0", "int"},
+		{"ro.product.model", "u:object_r:build_prop:s0", "string"},
+		{"ro.bootmode", "u:object_r:system_prop:s0", "string"},
+		{"ro.secure", "u:object_r:default_prop:s0", "string"},
+		{"sys.boot_completed", "u:object_r:system_prop:s0", "bool"},
+		{"sys.usb.state", "u:object_r:system_prop:s0", "string"},
+		{"persist.sys.timezone", "u:object_r:system_prop:s0", "string"},
+		{"debug.atrace.tags.enableflags", "u:object_r:default_prop:s0", "string"},
+		{"ro", "u:object_r:default_prop:s0", "string"},
+	}
+
+	for _, test := range tests {
+		if context, typ := info.Lookup(test.name); context != test.context || typ != test.typ {
+			t.Fatalf("Lookup(%q) = %q, %q, want %q, %q", test.name, context, typ, test.context, test.typ)
+		}
+	}
+
+	if contexts := info.Contexts(); len(contexts) != 3 {
+		t.Fatalf("Contexts() = %v", contexts)
+	}
+
+	data, err := os.ReadFile("testdata/__properties__/property_info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ParsePropertyInfo(data[:len(data)-16]); !errors.Is(err, ErrPropertyInfoCorrupted) {
+		t.Fatalf("truncated property_info: %v", err)
+	}
+}
+
+func TestAreas(t *testing.T) {
+	areas, err := OpenAreas(withAreas(t, t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]string{
+		"ro.build.version.sdk":          "29",
+		"ro.product.first_api_level":    "28",
+		"sys.boot_completed":            "1",
+		"persist.sys.timezone":          "Asia/Shanghai",
+		"ro.bootmode":                   "unknown",
+		"debug.atrace.tags.enableflags": "0",
+	}
+
+	for name, value := range tests {
+		if info, ok := areas.Find(name); !ok || info.Value != value {
+			t.Fatalf("Find(%q) = %+v, %v, want %q", name, info, ok, value)
+		}
+	}
+
+	if info, ok := areas.Find("ro.not.set"); ok {
+		t.Fatalf("Find(ro.not.set) = %+v", info)
+	}
+
+	if props := areas.Properties(); len(props) != 9 || props.Get("sys.usb.state") != "adb" {
+		t.Fatalf("Properties() = %v", props)
+	}
+}
+
+func TestAreasLegacy(t *testing.T) {
+	areas, err := OpenAreas("testdata/legacy_properties")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info, ok := areas.Find("persist.sys.country"); !ok || info.Value != "CN" {
+		t.Fatalf("Find(persist.sys.country) = %+v, %v", info, ok)
+	}
+	if props := areas.Properties(); len(props) != 3 {
+		t.Fatalf("Properties() = %v", props)
+	}
+}
+
+func TestGetAreas(t *testing.T) {
+	var dir = t.TempDir()
+	withAreas(t, dir)
+
+	var old = Root
+	Root = dir
+	Reload()
+	t.Cleanup(func() {
+		Root = old
+		Reload()
+	})
+
+	if value := Get("sys.boot_completed"); value != "1" {
+		t.Fatalf("sys.boot_completed = %q", value)
+	}
+	if api := GetInt("ro.product.first_api_level", 0); api != 28 {
+		t.Fatalf("ro.product.first_api_level = %d", api)
+	}
+
+	// The areas are opened once, until Reload.
+	if err := os.RemoveAll(filepath.Join(dir, "dev/__properties__")); err != nil {
+		t.Fatal(err)
+	}
+	if value := Get("sys.boot_completed"); value != "1" {
+		t.Fatalf("sys.boot_completed = %q before Reload", value)
+	}
+	Reload()
+	if value := Get("sys.boot_completed"); value != "" {
+		t.Fatalf("sys.boot_completed = %q after Reload", value)
+	}
+}
@@ -0,0 +1,192 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: property.go
+ * @Package: property
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 18:05
+ */
+
+package property
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Root is the directory the partitions are resolved against by Get. It is
+// "/" on a device, and can be pointed at an extracted image or a fixture tree.
+var Root = "/"
+
+// Properties maps property names to their values.
+type Properties map[string]string
+
+// Get returns the value of the property name, or "" if it's not set.
+func (p Properties) Get(name string) string {
+	return p[name]
+}
+
+// GetInt returns the value of the property name as an integer, or def if
+// it's not set or not an integer.
+func (p Properties) GetInt(name string, def int) int {
+	value, err := strconv.Atoi(p.Get(name))
+	if err != nil {
+		return def
+	}
+
+	return value
+}
+
+// LoadFile reads the property file path into props. Properties loaded from
+// a later file override the ones loaded from earlier files.
+func LoadFile(path string, props Properties) (err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var scanner = bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || strings.HasPrefix(line, "import ") {
+			continue
+		}
+
+		var index = strings.IndexByte(line, '=')
+		if index < 0 {
+			continue
+		}
+
+		var key, value = strings.TrimSpace(line[:index]), strings.TrimSpace(line[index+1:])
+		if key != "" {
+			props[key] = value
+		}
+	}
+
+	return scanner.Err()
+}
+
+// loadFirst loads the first of the property files that exists.
+func loadFirst(root string, props Properties, paths ...string) (loaded bool, err error) {
+	for _, path := range paths {
+		if err = LoadFile(filepath.Join(root, path), props); err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return
+		}
+	}
+
+	return false, nil
+}
+
+// Load reads the build-time properties of the partitions below root, in the
+// order init loads them in PropertyLoadBootDefaults. The more a partition is
+// specific to a product, the higher its precedence is. Files which can't be
+// read are skipped, the first such error is returned along with props.
+func Load(root string) (props Properties, err error) {
+	props = make(Properties)
+
+	var load = func(paths ...string) bool {
+		loaded, e := loadFirst(root, props, paths...)
+		if e != nil && err == nil {
+			err = e
+		}
+		return loaded
+	}
+
+	// /<part>/etc/build.prop is the canonical location of the build-time properties since S,
+	// falling back to /<part>/default.prop and /<part>/build.prop on older partitions.
+	var loadPartition = func(partition string) {
+		if !load("/" + partition + "/etc/build.prop") {
+			load("/" + partition + "/default.prop")
+			load("/" + partition + "/build.prop")
+		}
+	}
+
+	load("/system/etc/prop.default", "/prop.default", "/default.prop")
+	load("/system/build.prop")
+	loadPartition("system_ext")
+	load("/system_dlkm/etc/build.prop")
+	load("/vendor/default.prop")
+	load("/vendor/build.prop")
+	load("/vendor_dlkm/etc/build.prop")
+	load("/odm_dlkm/etc/build.prop")
+	loadPartition("odm")
+	loadPartition("product")
+
+	return
+}
+
+// The properties cached by Get: the build-time properties, nil until
+// loaded, and the runtime property areas, nil if there are none.
+var (
+	mutex  sync.RWMutex
+	loaded bool
+	cache  Properties
+	areas  *Areas
+)
+
+// cached returns the build-time properties and the runtime property areas
+// below Root, opening them first if needed.
+func cached() (Properties, *Areas) {
+	mutex.RLock()
+	var props, runtime, ok = cache, areas, loaded
+	mutex.RUnlock()
+	if ok {
+		return props, runtime
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Load builds a new map, so the one handed out earlier isn't touched.
+	if !loaded {
+		cache, _ = Load(Root)
+		areas, _ = OpenAreas(filepath.Join(Root, "dev/__properties__"))
+		loaded = true
+	}
+
+	return cache, areas
+}
+
+// Get returns the value of the property name below Root, or "" if it's not
+// set. The runtime properties of Root/dev/__properties__ are looked up
+// first, then the build-time properties. Both are read once, call Reload
+// after changing Root or to see changed runtime properties.
+func Get(name string) string {
+	var props, runtime = cached()
+	if runtime != nil {
+		if info, ok := runtime.Find(name); ok {
+			return info.Value
+		}
+	}
+
+	return props.Get(name)
+}
+
+// GetInt returns the value of the property name below Root as an integer,
+// or def if it's not set or not an integer.
+func GetInt(name string, def int) int {
+	value, err := strconv.Atoi(Get(name))
+	if err != nil {
+		return def
+	}
+
+	return value
+}
+
+// Reload drops the properties cached by Get.
+func Reload() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	loaded, cache, areas = false, nil, nil
+}
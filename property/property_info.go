@@ -0,0 +1,376 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: property_info.go
+ * @Package: property
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 19:55
+ */
+
+package property
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+)
+
+// The layout of /dev/__properties__/property_info, the trie built from the
+// property_contexts files by property_info_serializer, which tells the
+// context and the type of every property:
+//
+//	struct PropertyInfoAreaHeader {
+//	    uint32_t current_version;
+//	    uint32_t minimum_supported_version;
+//	    uint32_t size;
+//	    uint32_t contexts_offset;
+//	    uint32_t types_offset;
+//	    uint32_t root_offset;
+//	};
+//
+//	struct PropertyEntry {
+//	    uint32_t name_offset;
+//	    uint32_t namelen;
+//	    uint32_t context_index; // ~0u if none
+//	    uint32_t type_index;    // ~0u if none
+//	};
+//
+//	struct TrieNodeInternal {
+//	    uint32_t property_entry;
+//	    uint32_t num_child_nodes;
+//	    uint32_t child_nodes;
+//	    uint32_t num_prefixes;
+//	    uint32_t prefix_entries;
+//	    uint32_t num_exact_matches;
+//	    uint32_t exact_match_entries;
+//	};
+//
+// The contexts and types tables are a count followed by the offsets of the
+// strings. All the offsets are relative to the start of the file.
+const (
+	propertyInfoVersion    = 1
+	propertyInfoHeaderSize = 24
+	propertyInfoNone       = ^uint32(0)
+)
+
+var (
+	ErrNotPropertyInfo       = errors.New("not a supported property_info")
+	ErrPropertyInfoCorrupted = errors.New("property_info is corrupted")
+)
+
+type propertyEntry struct {
+	name    string
+	context uint32
+	typ     uint32
+}
+
+type propertyTrieNode struct {
+	propertyEntry
+	children []*propertyTrieNode
+	prefixes []propertyEntry
+	exacts   []propertyEntry
+}
+
+// PropertyInfo is a decoded property_info trie.
+type PropertyInfo struct {
+	contexts []string
+	types    []string
+	root     *propertyTrieNode
+}
+
+type propertyInfoDecoder struct {
+	data    []byte
+	visited map[uint32]bool
+}
+
+func (d *propertyInfoDecoder) uint32(off uint32) (uint32, error) {
+	if uint64(off)+4 > uint64(len(d.data)) {
+		return 0, ErrPropertyInfoCorrupted
+	}
+
+	return binary.LittleEndian.Uint32(d.data[off:]), nil
+}
+
+func (d *propertyInfoDecoder) cstring(off uint32) (string, error) {
+	if uint64(off) >= uint64(len(d.data)) {
+		return "", ErrPropertyInfoCorrupted
+	}
+
+	var end = bytes.IndexByte(d.data[off:], 0)
+	if end < 0 {
+		return "", ErrPropertyInfoCorrupted
+	}
+
+	return string(d.data[off : off+uint32(end)]), nil
+}
+
+// array decodes count u32 fields starting at off.
+func (d *propertyInfoDecoder) array(off, count uint32) (values []uint32, err error) {
+	if uint64(off)+uint64(count)*4 > uint64(len(d.data)) {
+		return nil, ErrPropertyInfoCorrupted
+	}
+
+	values = make([]uint32, count)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(d.data[off+uint32(i)*4:])
+	}
+
+	return
+}
+
+func (d *propertyInfoDecoder) strings(off uint32) (strs []string, err error) {
+	count, err := d.uint32(off)
+	if err != nil {
+		return
+	}
+
+	offsets, err := d.array(off+4, count)
+	if err != nil {
+		return
+	}
+
+	strs = make([]string, count)
+	for i, offset := range offsets {
+		if strs[i], err = d.cstring(offset); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (d *propertyInfoDecoder) entry(off uint32) (entry propertyEntry, err error) {
+	fields, err := d.array(off, 4)
+	if err != nil {
+		return
+	}
+
+	if entry.name, err = d.cstring(fields[0]); err != nil {
+		return
+	}
+	if uint32(len(entry.name)) != fields[1] {
+		return entry, ErrPropertyInfoCorrupted
+	}
+	entry.context, entry.typ = fields[2], fields[3]
+
+	return
+}
+
+func (d *propertyInfoDecoder) entries(off, count uint32) (entries []propertyEntry, err error) {
+	offsets, err := d.array(off, count)
+	if err != nil {
+		return
+	}
+
+	entries = make([]propertyEntry, count)
+	for i, offset := range offsets {
+		if entries[i], err = d.entry(offset); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (d *propertyInfoDecoder) node(off uint32) (node *propertyTrieNode, err error) {
+	// A node reached twice means the trie loops.
+	if d.visited[off] {
+		return nil, ErrPropertyInfoCorrupted
+	}
+	d.visited[off] = true
+
+	fields, err := d.array(off, 7)
+	if err != nil {
+		return
+	}
+
+	node = new(propertyTrieNode)
+	if node.propertyEntry, err = d.entry(fields[0]); err != nil {
+		return
+	}
+	if node.prefixes, err = d.entries(fields[4], fields[3]); err != nil {
+		return
+	}
+	if node.exacts, err = d.entries(fields[6], fields[5]); err != nil {
+		return
+	}
+
+	children, err := d.array(fields[2], fields[1])
+	if err != nil {
+		return
+	}
+
+	node.children = make([]*propertyTrieNode, len(children))
+	for i, child := range children {
+		if node.children[i], err = d.node(child); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ParsePropertyInfo decodes the property_info image data.
+func ParsePropertyInfo(data []byte) (info *PropertyInfo, err error) {
+	if len(data) < propertyInfoHeaderSize {
+		return nil, ErrNotPropertyInfo
+	}
+
+	var d = &propertyInfoDecoder{data: data, visited: make(map[uint32]bool)}
+	header, _ := d.array(0, propertyInfoHeaderSize/4)
+	if header[1] > propertyInfoVersion {
+		return nil, ErrNotPropertyInfo
+	}
+	if uint64(header[2]) > uint64(len(data)) {
+		return nil, ErrPropertyInfoCorrupted
+	}
+	d.data = data[:header[2]]
+
+	info = new(PropertyInfo)
+	if info.contexts, err = d.strings(header[3]); err != nil {
+		return nil, err
+	}
+	if info.types, err = d.strings(header[4]); err != nil {
+		return nil, err
+	}
+	if info.root, err = d.node(header[5]); err != nil {
+		return nil, err
+	}
+
+	// Every index must point into the tables.
+	var check func(entries ...propertyEntry) bool
+	check = func(entries ...propertyEntry) bool {
+		for _, entry := range entries {
+			if entry.context != propertyInfoNone && entry.context >= uint32(len(info.contexts)) {
+				return false
+			}
+			if entry.typ != propertyInfoNone && entry.typ >= uint32(len(info.types)) {
+				return false
+			}
+		}
+		return true
+	}
+	var walk func(node *propertyTrieNode) bool
+	walk = func(node *propertyTrieNode) bool {
+		if !check(node.propertyEntry) || !check(node.prefixes...) || !check(node.exacts...) {
+			return false
+		}
+		for _, child := range node.children {
+			if !walk(child) {
+				return false
+			}
+		}
+		return true
+	}
+	if !walk(info.root) {
+		return nil, ErrPropertyInfoCorrupted
+	}
+
+	return
+}
+
+// ReadPropertyInfo reads and decodes the property_info file path.
+func ReadPropertyInfo(path string) (info *PropertyInfo, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	return ParsePropertyInfo(data)
+}
+
+// Contexts returns the SELinux contexts of the properties, which are also
+// the names of the area files holding them.
+func (p *PropertyInfo) Contexts() []string {
+	return p.contexts
+}
+
+// Types returns the types of the properties, such as "string" or "bool".
+func (p *PropertyInfo) Types() []string {
+	return p.types
+}
+
+// checkPrefixMatch applies the first prefix of node which remaining starts with.
+func checkPrefixMatch(remaining string, node *propertyTrieNode, context, typ *uint32) {
+	for _, prefix := range node.prefixes {
+		if strings.HasPrefix(remaining, prefix.name) {
+			if prefix.context != propertyInfoNone {
+				*context = prefix.context
+			}
+			if prefix.typ != propertyInfoNone {
+				*typ = prefix.typ
+			}
+			return
+		}
+	}
+}
+
+// Lookup returns the context and the type of the property name, the way
+// PropertyInfoArea::GetPropertyInfoIndexes does: the most specific of the
+// '.' separated nodes, prefixes and exact matches wins. Either is "" if
+// property_contexts doesn't tell.
+func (p *PropertyInfo) Lookup(name string) (context, typ string) {
+	var (
+		contextIndex = propertyInfoNone
+		typeIndex    = propertyInfoNone
+		node         = p.root
+		remaining    = name
+	)
+
+	for {
+		if node.context != propertyInfoNone {
+			contextIndex = node.context
+		}
+		if node.typ != propertyInfoNone {
+			typeIndex = node.typ
+		}
+		checkPrefixMatch(remaining, node, &contextIndex, &typeIndex)
+
+		segment, rest, ok := strings.Cut(remaining, ".")
+		if !ok {
+			break
+		}
+
+		var child *propertyTrieNode
+		for _, n := range node.children {
+			if n.name == segment {
+				child = n
+				break
+			}
+		}
+		if child == nil {
+			break
+		}
+
+		node, remaining = child, rest
+	}
+
+	var matched bool
+	for _, exact := range node.exacts {
+		if exact.name == remaining {
+			if exact.context != propertyInfoNone {
+				contextIndex = exact.context
+			}
+			if exact.typ != propertyInfoNone {
+				typeIndex = exact.typ
+			}
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		checkPrefixMatch(remaining, node, &contextIndex, &typeIndex)
+	}
+
+	if contextIndex != propertyInfoNone {
+		context = p.contexts[contextIndex]
+	}
+	if typeIndex != propertyInfoNone {
+		typ = p.types[typeIndex]
+	}
+
+	return
+}
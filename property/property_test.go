@@ -0,0 +1,76 @@
+package property
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	props, err := Load("testdata/root")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = map[string]string{
+		"ro.secure":                        "1",
+		"ro.debuggable":                    "1",
+		"persist.sys.usb.config":           "adb",
+		"ro.build.version.sdk":             "28",
+		"ro.product.first_api_level":       "25",
+		"ro.product.model":                 "CM311-1a",
+		"ro.odm.build.version.sdk":         "28",
+		"ro.system_ext.legacy":             "",
+		"ro.system_dlkm.build.version.sdk": "33",
+		"ro.vendor.build.version.sdk":      "25",
+		"ro.not.set":                       "",
+	}
+
+	for name, value := range tests {
+		if got := props.Get(name); got != value {
+			t.Fatalf("Get(%q) = %q, want %q", name, got, value)
+		}
+	}
+
+	if len(props) != 10 {
+		t.Fatalf("loaded %d properties: %v", len(props), props)
+	}
+}
+
+func TestGet(t *testing.T) {
+	var old = Root
+	Root = "testdata/root"
+	Reload()
+	t.Cleanup(func() {
+		Root = old
+		Reload()
+	})
+
+	if api := GetInt("ro.product.first_api_level", 0); api != 25 {
+		t.Fatalf("ro.product.first_api_level = %d", api)
+	}
+	if api := GetInt("ro.product.model", -1); api != -1 {
+		t.Fatalf("ro.product.model = %d", api)
+	}
+}
+
+func TestReloadConcurrent(t *testing.T) {
+	var old = Root
+	Root = "testdata/root"
+	Reload()
+	t.Cleanup(func() {
+		Root = old
+		Reload()
+	})
+
+	var done = make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			Reload()
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		if api := GetInt("ro.product.first_api_level", 0); api != 25 {
+			t.Fatalf("ro.product.first_api_level = %d", api)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,216 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: audit.go
+ * @Package: main
+ * @Version: 1.0.0
+ * @Date: 2026/10/19 11:05
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Audit configures where the invocations of su are recorded.
+type Audit struct {
+	File string `yaml:"file" json:"file"` // JSON lines log, defaultAuditFile if empty
+	Logd bool   `yaml:"logd" json:"logd"` // also write the records to logd
+}
+
+const defaultAuditFile = "/data/misc/su/audit.log"
+
+// The datagram socket of logd, see liblog logd_writer.cpp.
+var logdSocket = "/dev/socket/logdw"
+
+const (
+	logIDMain   = 0
+	logPrioInfo = 4
+	logPrioWarn = 5
+	logTag      = "su"
+)
+
+// auditRecord is an invocation of su.
+type auditRecord struct {
+	Time        time.Time `json:"time"`
+	UID         uint32    `json:"uid"`    // caller uid
+	PID         int       `json:"pid"`    // pid of su, or of the client of the daemon
+	PPID        int       `json:"ppid"`   // pid of the caller
+	Parent      []string  `json:"parent"` // cmdline of the caller
+	TargetUID   int       `json:"target_uid"`
+	TargetGID   int       `json:"target_gid"`
+	Groups      []int     `json:"groups"`
+	Caps        string    `json:"caps,omitempty"`         // capabilities kept by the target
+	Context     string    `json:"context,omitempty"`      // SELinux context of su
+	ExecContext string    `json:"exec_context,omitempty"` // SELinux context of the command
+	Command     string    `json:"command"`
+	Args        []string  `json:"args"`
+	Allowed     bool      `json:"allowed"`
+}
+
+// processCmdline returns the command line of the process pid.
+func processCmdline(pid int) []string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	return strings.Split(string(bytes.TrimRight(data, "\x00")), "\x00")
+}
+
+// parentPID returns the parent of the process pid, 0 if it's gone.
+func parentPID(pid int) int {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0
+	}
+
+	// pid (comm) state ppid ..., comm may hold spaces and parentheses.
+	var fields = strings.Fields(string(data[bytes.LastIndexByte(data, ')')+1:]))
+	if len(fields) < 2 {
+		return 0
+	}
+
+	ppid, _ := strconv.Atoi(fields[1])
+	return ppid
+}
+
+// caller is the process su switches for: su itself, or the client of the
+// daemon.
+type caller struct {
+	uid uint32
+	pid int
+}
+
+func currentCaller() caller {
+	return caller{uint32(os.Getuid()), os.Getpid()}
+}
+
+func newAuditRecord(c caller, uid, gid int, gids []int, command string, args []string, allowed bool) *auditRecord {
+	var ppid = parentPID(c.pid)
+
+	return &auditRecord{
+		Time:      time.Now(),
+		UID:       c.uid,
+		PID:       c.pid,
+		PPID:      ppid,
+		Parent:    processCmdline(ppid),
+		TargetUID: uid,
+		TargetGID: gid,
+		Groups:    gids,
+		Command:   command,
+		Args:      args,
+		Allowed:   allowed,
+	}
+}
+
+func (r *auditRecord) String() string {
+	var result = "allowed"
+	if !r.Allowed {
+		result = "denied"
+	}
+
+	return fmt.Sprintf("uid %d (pid %d, parent %d %q) %s to run %q as uid %d gid %d",
+		r.UID, r.PID, r.PPID, strings.Join(r.Parent, " "), result, strings.Join(r.Args, " "), r.TargetUID, r.TargetGID)
+}
+
+// writeFile appends the record to the JSON lines file path, which only root
+// can read.
+func (r *auditRecord) writeFile(path string) (err error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	// A single write keeps the concurrent records apart.
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		return
+	}
+
+	return file.Sync()
+}
+
+// logdPacket encodes the record in the binary format logd reads from its
+// socket: the android_log_header_t, then the priority, the tag and the
+// message, both NUL terminated.
+//
+//	struct android_log_header_t {
+//	    uint8_t id;
+//	    uint16_t tid;
+//	    log_time realtime; // uint32_t tv_sec, tv_nsec
+//	} __attribute__((__packed__));
+func (r *auditRecord) logdPacket() []byte {
+	var packet bytes.Buffer
+
+	var header [11]byte
+	header[0] = logIDMain
+	binary.LittleEndian.PutUint16(header[1:], uint16(syscall.Gettid()))
+	binary.LittleEndian.PutUint32(header[3:], uint32(r.Time.Unix()))
+	binary.LittleEndian.PutUint32(header[7:], uint32(r.Time.Nanosecond()))
+	packet.Write(header[:])
+
+	var prio byte = logPrioInfo
+	if !r.Allowed {
+		prio = logPrioWarn
+	}
+	packet.WriteByte(prio)
+	packet.WriteString(logTag)
+	packet.WriteByte(0)
+	packet.WriteString(r.String())
+	packet.WriteByte(0)
+
+	return packet.Bytes()
+}
+
+func (r *auditRecord) writeLogd() (err error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: logdSocket, Net: "unixgram"})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Write(r.logdPacket())
+
+	return
+}
+
+// write records the invocation as configured. Failures are reported on
+// stderr but don't stop su.
+func (r *auditRecord) write(config Audit) {
+	var path = config.File
+	if path == "" {
+		path = defaultAuditFile
+	}
+
+	if err := r.writeFile(path); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "su: audit: %v\n", err)
+	}
+
+	if config.Logd {
+		if err := r.writeLogd(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "su: audit: %v\n", err)
+		}
+	}
+}
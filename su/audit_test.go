@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditFile(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "su", "audit.log")
+
+	for _, allowed := range []bool{true, false} {
+		var record = newAuditRecord(currentCaller(), 0, 0, []int{0, 3003}, "/system/bin/sh", []string{"/system/bin/sh", "-c", "id"}, allowed)
+		if err := record.writeFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Mode().Perm() != 0600 {
+		t.Fatalf("audit log mode = %v", stat.Mode())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("audit log = %q", data)
+	}
+
+	var record auditRecord
+	if err = json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Allowed || record.PID != os.Getpid() || record.PPID != os.Getppid() || len(record.Parent) == 0 ||
+		record.Command != "/system/bin/sh" || len(record.Args) != 3 || len(record.Groups) != 2 {
+		t.Fatalf("record = %+v", record)
+	}
+}
+
+func TestAuditLogd(t *testing.T) {
+	var old = logdSocket
+	logdSocket = filepath.Join(t.TempDir(), "logdw")
+	t.Cleanup(func() { logdSocket = old })
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: logdSocket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var record = newAuditRecord(caller{2000, os.Getpid()}, 1000, 1000, nil, "/system/bin/id", []string{"/system/bin/id"}, false)
+	if err = record.writeLogd(); err != nil {
+		t.Fatal(err)
+	}
+
+	var packet = make([]byte, 4096)
+	n, err := conn.Read(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet = packet[:n]
+
+	if packet[0] != logIDMain || binary.LittleEndian.Uint32(packet[3:]) != uint32(record.Time.Unix()) {
+		t.Fatalf("header = %x", packet[:11])
+	}
+
+	var payload = bytes.Split(packet[11:], []byte{0})
+	if len(payload) != 3 || payload[0][0] != logPrioWarn || string(payload[0][1:]) != logTag ||
+		!strings.Contains(string(payload[1]), "denied") || len(payload[2]) != 0 {
+		t.Fatalf("payload = %q", packet[11:])
+	}
+}
@@ -0,0 +1,104 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: caps.go
+ * @Package: main
+ * @Version: 1.0.0
+ * @Date: 2026/10/19 19:05
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// See include/uapi/linux/prctl.h and include/uapi/linux/capability.h. The
+// capability sets belong to the thread, so the functions below only change
+// the calling one.
+const (
+	prCapAmbient            = 47
+	prCapAmbientRaise       = 2
+	linuxCapabilityVersion3 = 0x20080522
+)
+
+// errNoAmbient reports a kernel without ambient capabilities, before Linux
+// 4.3. The capabilities are then only inheritable and permitted, and only
+// survive the exec of a program with file capabilities.
+var errNoAmbient = errors.New("ambient capabilities not supported")
+
+func prctl(option, arg2, arg3 uintptr) error {
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, option, arg2, arg3, 0, 0, 0); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// setKeepCaps keeps the permitted capabilities across the switch from root
+// to another uid.
+func setKeepCaps(keep bool) error {
+	var arg uintptr
+	if keep {
+		arg = 1
+	}
+
+	return prctl(syscall.PR_SET_KEEPCAPS, arg, 0)
+}
+
+// dropBounding drops the capabilities not in keep from the bounding set,
+// which needs CAP_SETPCAP, so before the switch.
+func dropBounding(keep uint64) error {
+	for c := uint(0); c < 64; c++ {
+		if keep&(1<<c) != 0 {
+			continue
+		}
+
+		// EINVAL marks the capabilities the kernel doesn't know.
+		if err := prctl(syscall.PR_CAPBSET_DROP, uintptr(c), 0); errors.Is(err, syscall.EINVAL) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("drop capability %d from the bounding set: %w", c, err)
+		}
+	}
+
+	return nil
+}
+
+// setCapabilities sets the effective, permitted and inheritable sets to
+// mask, and raises the ambient set to mask, so the capabilities survive the
+// exec of a program as a uid other than root. It returns errNoAmbient once
+// the other sets are set if the kernel has no ambient set.
+func setCapabilities(mask uint64) error {
+	var header = struct {
+		version uint32
+		pid     int32
+	}{linuxCapabilityVersion3, 0}
+
+	var data [2]struct{ effective, permitted, inheritable uint32 }
+	for i := range data {
+		var set = uint32(mask >> (32 * i))
+		data[i].effective, data[i].permitted, data[i].inheritable = set, set, set
+	}
+
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return fmt.Errorf("capset: %w", errno)
+	}
+
+	for c := uint(0); c < 64; c++ {
+		if mask&(1<<c) == 0 {
+			continue
+		}
+		if err := prctl(prCapAmbient, prCapAmbientRaise, uintptr(c)); errors.Is(err, syscall.EINVAL) {
+			return errNoAmbient
+		} else if err != nil {
+			return fmt.Errorf("raise ambient capability %d: %w", c, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/zooyer/android/user"
+)
+
+func TestSetCapabilities(t *testing.T) {
+	var mask = user.CapMaskLong(user.CapNetAdmin) | user.CapMaskLong(user.CapSysNice)
+
+	// The switch as su does it, in a child running the test binary.
+	if os.Getenv("SU_TEST_CAPS") == "1" {
+		runtime.LockOSThread()
+		for _, step := range []func() error{
+			func() error { return setKeepCaps(true) },
+			func() error { return dropBounding(mask) },
+			func() error { return syscall.Setuid(2000) },
+			func() error { return setCapabilities(mask) },
+		} {
+			if err := step(); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		_ = syscall.Exec("/bin/grep", []string{"grep", "^Cap", "/proc/self/status"}, nil)
+		os.Exit(1)
+	}
+
+	if os.Geteuid() != 0 {
+		t.Skip("not root")
+	}
+
+	var cmd = exec.Command(os.Args[0], "-test.run=^TestSetCapabilities$")
+	cmd.Env = append(os.Environ(), "SU_TEST_CAPS=1")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, output)
+	}
+
+	var want = fmt.Sprintf("%016x", mask)
+	for _, set := range []string{"CapInh", "CapPrm", "CapEff", "CapBnd", "CapAmb"} {
+		if !strings.Contains(string(output), set+":\t"+want+"\n") {
+			t.Fatalf("%s not %s:\n%s", set, want, output)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: daemon.go
+ * @Package: main
+ * @Version: 1.0.0
+ * @Date: 2026/10/19 17:20
+ */
+
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// The abstract UNIX socket the daemon listens on, see unix(7).
+var daemonSocket = "@su_daemon"
+
+// The su the daemon runs for each request, as root with the hidden --caller
+// option in front of the arguments of the client.
+var daemonExecutable = "/proc/self/exe"
+
+// The file descriptor the daemon passes the environment and the working
+// directory of the client on. su runs with a fixed environment in "/", the
+// ones of the client are only used for the command, once it's allowed.
+const clientEnvFd = 3
+
+var errNoDaemon = errors.New("no su daemon")
+
+// The largest request the daemon reads.
+const maxDaemonRequest = 1 << 20
+
+// daemonRequest is what the client sends, along with its stdin, stdout and
+// stderr. It's framed by its length, a little endian uint32.
+//
+// The client then sends the signals it receives, as little endian uint32,
+// and the daemon answers with the exit status, a little endian int32.
+type daemonRequest struct {
+	Args []string `json:"args"` // the arguments of su
+	Env  []string `json:"env"`
+	Dir  string   `json:"dir"`
+}
+
+// peerCredentials returns the process on the other side of conn.
+func peerCredentials(conn *net.UnixConn) (cred *syscall.Ucred, err error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return
+	}
+
+	var credErr error
+	if err = raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return
+	}
+
+	return cred, credErr
+}
+
+// parseCaller parses the value of --caller, UID:PID.
+func parseCaller(value string) (c caller, err error) {
+	uid, pid, ok := strings.Cut(value, ":")
+	if !ok {
+		return c, fmt.Errorf("invalid caller '%s'", value)
+	}
+
+	id, err := strconv.ParseUint(uid, 10, 32)
+	if err != nil {
+		return c, fmt.Errorf("invalid caller '%s'", value)
+	}
+	if c.pid, err = strconv.Atoi(pid); err != nil {
+		return c, fmt.Errorf("invalid caller '%s'", value)
+	}
+	c.uid = uint32(id)
+
+	return
+}
+
+// readRequest reads the request and the files of the client.
+func readRequest(conn *net.UnixConn) (req *daemonRequest, files []*os.File, err error) {
+	var (
+		header [4]byte
+		oob    = make([]byte, syscall.CmsgSpace(3*4))
+	)
+
+	// The files come along with the first bytes.
+	n, oobn, _, _, err := conn.ReadMsgUnix(header[:], oob)
+	if err != nil {
+		return
+	}
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return
+	}
+	for _, message := range messages {
+		fds, err := syscall.ParseUnixRights(&message)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			files = append(files, os.NewFile(uintptr(fd), "client"))
+		}
+	}
+	defer func() {
+		if err != nil {
+			closeFiles(files)
+		}
+	}()
+
+	if len(files) != 3 {
+		return nil, nil, fmt.Errorf("got %d files, want stdin, stdout and stderr", len(files))
+	}
+
+	if _, err = io.ReadFull(conn, header[n:]); err != nil {
+		return nil, nil, err
+	}
+
+	var size = binary.LittleEndian.Uint32(header[:])
+	if size > maxDaemonRequest {
+		return nil, nil, fmt.Errorf("request too large: %d bytes", size)
+	}
+
+	var data = make([]byte, size)
+	if _, err = io.ReadFull(conn, data); err != nil {
+		return nil, nil, err
+	}
+
+	req = new(daemonRequest)
+	if err = json.Unmarshal(data, req); err != nil {
+		return nil, nil, err
+	}
+
+	return
+}
+
+func closeFiles(files []*os.File) {
+	for _, file := range files {
+		_ = file.Close()
+	}
+}
+
+// serveClient runs su for the client on conn, as the uid the kernel reports
+// for it, and sends back the exit status.
+func serveClient(conn *net.UnixConn) (err error) {
+	defer conn.Close()
+
+	cred, err := peerCredentials(conn)
+	if err != nil {
+		return
+	}
+
+	req, files, err := readRequest(conn)
+	if err != nil {
+		return fmt.Errorf("pid %d: %w", cred.Pid, err)
+	}
+	defer closeFiles(files)
+
+	envReader, envWriter, err := os.Pipe()
+	if err != nil {
+		return
+	}
+	defer envWriter.Close()
+
+	var cmd = &exec.Cmd{
+		Path:        daemonExecutable,
+		Args:        append([]string{"su", fmt.Sprintf("--caller=%d:%d", cred.Uid, cred.Pid)}, req.Args...),
+		Env:         []string{"PATH=" + defPath()},
+		Dir:         "/",
+		Stdin:       files[0],
+		Stdout:      files[1],
+		Stderr:      files[2],
+		ExtraFiles:  []*os.File{envReader}, // clientEnvFd
+		SysProcAttr: &syscall.SysProcAttr{Setsid: true},
+	}
+
+	// An interactive shell needs the terminal of the client as its
+	// controlling terminal for job control.
+	if _, ok := terminal(files[0]); ok {
+		cmd.SysProcAttr.Setctty = true
+		cmd.SysProcAttr.Ctty = 0 // the stdin of su
+	}
+
+	var status int32 = 1
+	err = cmd.Start()
+	_ = envReader.Close()
+	if err != nil {
+		_, _ = fmt.Fprintf(files[2], "su: %v\n", err)
+	} else {
+		go func() {
+			_ = json.NewEncoder(envWriter).Encode(&daemonRequest{Env: req.Env, Dir: req.Dir})
+			_ = envWriter.Close()
+		}()
+
+		// Pass on the signals of the client, and hang up the command
+		// if the client goes away.
+		go func() {
+			var sig [4]byte
+			for {
+				if _, err := io.ReadFull(conn, sig[:]); err != nil {
+					_ = cmd.Process.Signal(syscall.SIGHUP)
+					return
+				}
+				if sig, ok := clientSignal(binary.LittleEndian.Uint32(sig[:])); ok {
+					_ = cmd.Process.Signal(sig)
+				}
+			}
+		}()
+
+		_ = cmd.Wait()
+		status = int32(exitStatus(cmd.ProcessState))
+	}
+
+	return binary.Write(conn, binary.LittleEndian, status)
+}
+
+// clientSignal returns the signal number a client sent, if it's one su
+// passes on. The others, as SIGKILL or SIGSTOP, are dropped.
+func clientSignal(number uint32) (os.Signal, bool) {
+	for _, sig := range ptySignals {
+		if sig == syscall.Signal(number) {
+			return sig, true
+		}
+	}
+
+	return nil, false
+}
+
+// readClient reads the environment and the working directory of the client
+// the daemon passes to su.
+func readClient() (env []string, dir string, err error) {
+	var file = os.NewFile(clientEnvFd, "env")
+	defer file.Close()
+
+	var req daemonRequest
+	if err = json.NewDecoder(io.LimitReader(file, maxDaemonRequest)).Decode(&req); err != nil {
+		return
+	}
+
+	return req.Env, req.Dir, nil
+}
+
+// runDaemon serves the clients on the socket name until it fails.
+func runDaemon(name string) error {
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: name, Net: "unix"})
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	return serveDaemon(listener)
+}
+
+func serveDaemon(listener *net.UnixListener) error {
+	for {
+		conn, err := listener.AcceptUnix()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+
+		go func() {
+			if err := serveClient(conn); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "su: daemon: %v\n", err)
+			}
+		}()
+	}
+}
+
+// The uid the daemon must run as, anyone else could have taken its name.
+var daemonOwner uint32 = 0
+
+// runClient has the daemon on the socket name run su with args, env and
+// dir, on the files stdin, stdout and stderr. It returns the exit status.
+func runClient(name string, args, env []string, dir string, files [3]*os.File) (status int, err error) {
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: name, Net: "unix"})
+	if err != nil {
+		return 1, fmt.Errorf("%w: %v", errNoDaemon, err)
+	}
+	defer conn.Close()
+
+	// Hand nothing to a process squatting the name of the daemon.
+	cred, err := peerCredentials(conn)
+	if err != nil {
+		return 1, fmt.Errorf("daemon: %w", err)
+	}
+	if cred.Uid != daemonOwner {
+		return 1, fmt.Errorf("daemon: %s is served by uid %d, not %d", name, cred.Uid, daemonOwner)
+	}
+
+	data, err := json.Marshal(&daemonRequest{Args: args, Env: env, Dir: dir})
+	if err != nil {
+		return
+	}
+	var header [4]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(len(data)))
+	data = append(header[:], data...)
+
+	var rights = syscall.UnixRights(int(files[0].Fd()), int(files[1].Fd()), int(files[2].Fd()))
+	if _, _, err = conn.WriteMsgUnix(data, rights, nil); err != nil {
+		return
+	}
+
+	var signals = make(chan os.Signal, 8)
+	signal.Notify(signals, ptySignals...)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			_ = binary.Write(conn, binary.LittleEndian, uint32(sig.(syscall.Signal)))
+		}
+	}()
+
+	var result int32
+	if err = binary.Read(conn, binary.LittleEndian, &result); err != nil {
+		return 1, fmt.Errorf("daemon: %w", err)
+	}
+
+	return int(result), nil
+}
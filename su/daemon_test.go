@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestParseCaller(t *testing.T) {
+	if c, err := parseCaller("2000:1234"); err != nil || c != (caller{2000, 1234}) {
+		t.Fatalf("parseCaller(2000:1234) = %+v, %v", c, err)
+	}
+
+	for _, value := range []string{"", "2000", "shell:1234", "2000:", "-1:1"} {
+		if c, err := parseCaller(value); err == nil {
+			t.Fatalf("parseCaller(%q) = %+v", value, c)
+		}
+	}
+}
+
+func TestDaemon(t *testing.T) {
+	var dir = t.TempDir()
+
+	// A su printing what the daemon runs it with.
+	var exe = filepath.Join(dir, "su")
+	if err := os.WriteFile(exe, []byte("#!/bin/sh\necho \"$@\"\npwd\necho \"${SU_TEST:-unset}\"\ncat <&3\ncat\nexit 7\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var oldExe, oldOwner = daemonExecutable, daemonOwner
+	daemonExecutable, daemonOwner = exe, uint32(os.Getuid())
+	t.Cleanup(func() { daemonExecutable, daemonOwner = oldExe, oldOwner })
+
+	var name = fmt.Sprintf("@su_daemon_test_%d", os.Getpid())
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: name, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() { _ = serveDaemon(listener) }()
+
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = stdinW.WriteString("input\n")
+	_ = stdinW.Close()
+
+	status, err := runClient(name, []string{"-c", "id", "shell"}, []string{"SU_TEST=env"}, dir, [3]*os.File{stdinR, stdoutW, stdoutW})
+	_ = stdinR.Close()
+	_ = stdoutW.Close()
+	if err != nil || status != 7 {
+		t.Fatalf("runClient() = %d, %v", status, err)
+	}
+
+	output, err := io.ReadAll(stdoutR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = fmt.Sprintf("--caller=%d:%d -c id shell\n/\nunset\n{\"args\":null,\"env\":[\"SU_TEST=env\"],\"dir\":%q}\ninput\n", os.Getuid(), os.Getpid(), dir)
+	if string(output) != want {
+		t.Fatalf("output = %q, want %q", output, want)
+	}
+}
+
+func TestClientSignal(t *testing.T) {
+	for _, sig := range []syscall.Signal{syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM} {
+		if got, ok := clientSignal(uint32(sig)); !ok || got != sig {
+			t.Fatalf("clientSignal(%d) = %v, %v", sig, got, ok)
+		}
+	}
+
+	for _, sig := range []syscall.Signal{syscall.SIGKILL, syscall.SIGSTOP, syscall.SIGCONT, 0, 1000} {
+		if got, ok := clientSignal(uint32(sig)); ok {
+			t.Fatalf("clientSignal(%d) = %v", sig, got)
+		}
+	}
+}
+
+func TestClientNoDaemon(t *testing.T) {
+	var files = [3]*os.File{os.Stdin, os.Stdout, os.Stderr}
+	if _, err := runClient("@su_daemon_test_none", nil, nil, "/", files); !errors.Is(err, errNoDaemon) {
+		t.Fatalf("runClient() = %v", err)
+	}
+}
+
+func TestClientDaemonOwner(t *testing.T) {
+	var old = daemonOwner
+	daemonOwner = uint32(os.Getuid()) + 1
+	t.Cleanup(func() { daemonOwner = old })
+
+	var name = fmt.Sprintf("@su_daemon_test_owner_%d", os.Getpid())
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: name, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	var received = make(chan int, 1)
+	go func() {
+		conn, err := listener.AcceptUnix()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var oob = make([]byte, 64)
+		_, oobn, _, _, _ := conn.ReadMsgUnix(make([]byte, 4), oob)
+		received <- oobn
+	}()
+
+	var files = [3]*os.File{os.Stdin, os.Stdout, os.Stderr}
+	if _, err = runClient(name, []string{"id"}, []string{"TOKEN=secret"}, "/", files); err == nil || errors.Is(err, errNoDaemon) {
+		t.Fatalf("runClient() = %v", err)
+	}
+	if oobn := <-received; oobn != 0 {
+		t.Fatal("client passed its files to a daemon of another uid")
+	}
+}
@@ -15,6 +15,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
@@ -33,12 +35,31 @@ func help() {
 }
 
 func help13() {
-	fmt.Println("usage: su [--path,-p] [WHO [COMMAND...]]")
+	fmt.Println("usage: su [-lmp] [-s SHELL] [-c CMD] [--pty] [--daemon]")
+	fmt.Println("          [--caps CAPS] [--drop-bounding] [--context CONTEXT] [WHO [COMMAND...]]")
 	fmt.Println()
 	fmt.Println("Switch to WHO (default 'root') and run the given COMMAND (default sh).")
 	fmt.Println()
+	fmt.Println("-c, --command CMD           run CMD with the shell, also accepted after WHO")
+	fmt.Println("-s, --shell SHELL           run SHELL instead of the shell of WHO")
+	fmt.Println("-, -l, --login              start a login shell with a clean environment")
+	fmt.Println("-m, -p, --preserve-environment")
+	fmt.Println("                            keep the whole environment")
+	fmt.Println("--path                      keep the PATH of the caller")
+	fmt.Println("--pty                       run COMMAND in a new pseudo-terminal, relaying")
+	fmt.Println("                            the input, output, window size and signals")
+	fmt.Println("--caps CAP_X,CAP_Y          keep the capabilities as the target user")
+	fmt.Println("--drop-bounding             drop the other capabilities from the bounding set")
+	fmt.Println("--context CONTEXT           run COMMAND in the SELinux CONTEXT, e.g. u:r:su:s0")
+	fmt.Println("--daemon                    serve the su of the callers without root, which")
+	fmt.Println("                            run through the daemon when su isn't setuid root")
+	fmt.Println()
+	fmt.Println("A COMMAND without a '/' is looked up in the PATH.")
+	fmt.Println()
 	fmt.Println("WHO is a comma-separated list of user, group, and supplementary groups")
-	fmt.Println("in that order.")
+	fmt.Println("in that order. The user may also be an installed package name.")
+	fmt.Println()
+	fmt.Println("The callers allowed are set by su.yaml next to su, root and shell by default.")
 	fmt.Println()
 }
 
@@ -107,6 +128,17 @@ func defPath() string {
 	return strings.Join(list, ":")
 }
 
+// executableDir returns the directory of the su executable, which holds
+// the policy file.
+func executableDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Dir(exe)
+}
+
 func errorExit(status int, err error, msg string) {
 	_, _ = fmt.Fprintln(os.Stderr, msg)
 
@@ -132,6 +164,36 @@ func pwtoid(tok string) (uid, gid int) {
 	return
 }
 
+// groupList returns the supplementary groups of the user name, nil if name
+// isn't a user name. A package gets the gids of its permissions as well, as
+// in packages.list.
+func groupList(name string, gid int) (gids []int) {
+	if user.Getpwnam(name) == nil {
+		return
+	}
+
+	var groups = make([]uint32, 10)
+	ngroups, err := user.GetGroupList(name, uint32(gid), groups)
+	if err != nil {
+		ngroups = len(groups)
+	}
+	groups = groups[:ngroups]
+
+	if pkg := user.LookupPackage(name); pkg != nil {
+		groups = append(groups, pkg.GIDs...)
+	}
+
+	var seen = make(map[uint32]bool)
+	for _, group := range groups {
+		if !seen[group] {
+			seen[group] = true
+			gids = append(gids, int(group))
+		}
+	}
+
+	return
+}
+
 func extractUidGids(ids string) (uid, gid int, gids []int) {
 	if ids == "" {
 		return
@@ -139,18 +201,22 @@ func extractUidGids(ids string) (uid, gid int, gids []int) {
 
 	var tok = strings.Split(ids, ",")
 	uid, gid = pwtoid(tok[0])
-	if len(tok) < 2 {
-		// gid is already set above
-		return
+	if len(tok) > 1 {
+		_, gid = pwtoid(tok[1])
 	}
 
-	_, gid = pwtoid(tok[1])
-
 	for i := 2; i < len(tok); i++ {
 		_, gid := pwtoid(tok[i])
 		gids = append(gids, gid)
 	}
 
+	// Without supplementary groups, a named user other than root gets the
+	// groups it's a member of. Root, and a bare uid, keep the groups of the
+	// caller.
+	if len(tok) < 3 && uid != 0 {
+		gids = groupList(tok[0], gid)
+	}
+
 	if len(gids) >= 10 {
 		gids = gids[:10]
 		_, _ = fmt.Fprintln(os.Stderr, "too many group ids")
@@ -160,42 +226,165 @@ func extractUidGids(ids string) (uid, gid int, gids []int) {
 }
 
 func main() {
-	var (
-		err  error
-		args = os.Args[1:]
-	)
-
-	//if uid := os.Getuid(); uid != user.AidRoot && uid != user.AidShell {
-	//	errorExit(1, nil, "not allowed")
-	//}
+	// Accept installed package names as user, the way run-as does.
+	user.LookupPackageNames = true
 
 	var (
-		path     bool   // The inherits parent process path env.
 		uid, gid = 0, 0 // The default user is root.
+		gids     []int  // The supplementary groups.
 	)
 
+	// The daemon runs su for its clients with their credentials first.
+	var args, c, environ, dir = os.Args[1:], currentCaller(), os.Environ(), ""
+	if len(args) > 0 && strings.HasPrefix(args[0], "--caller=") {
+		if os.Getuid() != 0 {
+			errorExit(1, nil, "su: --caller is only for the daemon")
+		}
+		var err error
+		if c, err = parseCaller(strings.TrimPrefix(args[0], "--caller=")); err != nil {
+			errorExit(1, nil, fmt.Sprintf("su: %v", err))
+		}
+		if environ, dir, err = readClient(); err != nil {
+			errorExit(1, nil, fmt.Sprintf("su: client environment: %v", err))
+		}
+		args = args[1:]
+	}
+
+	opts, err := parseOptions(args)
+	if err != nil {
+		help13()
+		errorExit(1, nil, fmt.Sprintf("su: %v", err))
+	}
+
 	// Handle -h and --help.
-	if len(args) > 0 && (args[0] == "--help" || args[0] == "-h") {
+	if opts.help {
 		help13()
 		return
 	}
 
-	// Handle -p and --path.
-	if len(args) > 0 && (args[0] == "--path" || args[0] == "-p") {
-		path = true
-		args = args[1:]
+	if opts.daemon {
+		if os.Geteuid() != 0 {
+			errorExit(1, nil, "su: the daemon must run as root")
+		}
+		if err = runDaemon(daemonSocket); err != nil {
+			errorExit(1, err, fmt.Sprintf("su: daemon: %v", err))
+		}
+		return
+	}
+
+	// Without the setuid bit, have the daemon switch if there's one.
+	if os.Geteuid() != 0 {
+		wd, _ := os.Getwd()
+		status, err := runClient(daemonSocket, args, os.Environ(), wd, [3]*os.File{os.Stdin, os.Stdout, os.Stderr})
+		if err == nil {
+			os.Exit(status)
+		}
+		if !errors.Is(err, errNoDaemon) {
+			errorExit(1, nil, fmt.Sprintf("su: %v", err))
+		}
+	}
+
+	// Nothing is looked up for a caller no rule allows.
+	policy, err := loadPolicy(executableDir())
+	if err != nil {
+		errorExit(1, err, fmt.Sprintf("su: invalid policy: %v", err))
+	}
+	if !policy.allowCaller(c.uid) {
+		newAuditRecord(c, -1, -1, nil, "", args, false).write(policy.Audit)
+		errorExit(1, nil, fmt.Sprintf("su: uid %d not allowed", c.uid))
+	}
+
+	// WHO is the uid/gid/supplementary groups.
+	if opts.who != "" {
+		uid, gid, gids = extractUidGids(opts.who)
+	}
+
+	// The capabilities kept by the target user.
+	caps, err := user.ParseCapabilities(opts.caps)
+	if err != nil {
+		errorExit(1, nil, fmt.Sprintf("su: %v", err))
+	}
+	var keepCaps = opts.caps != "" || opts.dropBset
+
+	// Root gets the bounding set back with the exec, it only loses what's
+	// dropped from there.
+	if opts.caps != "" && uid == 0 && !opts.dropBset {
+		errorExit(1, nil, "su: --caps restricts nothing as root without --drop-bounding")
+	}
+
+	if opts.context != "" {
+		if err = validateContext(opts.context); err != nil {
+			errorExit(1, nil, fmt.Sprintf("su: %v", err))
+		}
+	}
+
+	// Set up the environment and the arguments for exec.
+	var pw = user.Getpwuid(uint32(uid))
+	var env = opts.environ(pw, environ)
+	path, execArgs, err := opts.execArgs(pw, env)
+	if err != nil {
+		errorExit(1, nil, fmt.Sprintf("su: %v", err))
+	}
+
+	// Check the caller against the policy before switching.
+	var rule = policy.Match(request{caller: c.uid, target: uint32(uid), command: path, caps: caps, context: opts.context})
+
+	// A rule restricting the commands only holds for the command found with
+	// the default PATH, which runs without the LD_* variables.
+	if rule != nil && len(rule.Commands) > 0 {
+		env = restrictEnv(env)
+		if restricted, _, err := opts.execArgs(pw, env); err != nil || restricted != path {
+			rule = nil
+		}
 	}
+	var allowed = rule != nil
 
-	// If there are any arguments, the first argument is the uid/gid/supplementary groups.
-	if len(args) > 0 {
-		var gids []int
-		uid, gid, gids = extractUidGids(args[0])
-		if len(gids) > 0 {
-			if err = syscall.Setgroups(gids); err != nil {
-				errorExit(1, err, "setgroups failed")
+	// Record the invocation while still privileged.
+	var record = newAuditRecord(c, uid, gid, gids, path, execArgs, allowed)
+	if keepCaps {
+		record.Caps = user.FormatCapabilities(caps)
+	}
+	record.Context, _ = currentContext()
+	record.ExecContext = opts.context
+	record.write(policy.Audit)
+
+	if !allowed {
+		errorExit(1, nil, fmt.Sprintf("su: uid %d not allowed to run the command as uid %d", c.uid, uid))
+	}
+
+	// The capability sets and the exec context belong to the thread, stay
+	// on it up to the exec.
+	runtime.LockOSThread()
+
+	if keepCaps {
+		if err = setKeepCaps(true); err != nil {
+			errorExit(1, err, "prctl(PR_SET_KEEPCAPS) failed")
+		}
+	}
+
+	if opts.context != "" {
+		if err = setExecContext(opts.context); err != nil {
+			var from string
+			if current, cerr := currentContext(); cerr == nil {
+				from = " from " + current
 			}
+			errorExit(1, nil, fmt.Sprintf("su: set context %s%s: %v", opts.context, from, err))
+		}
+	}
+
+	// Dropping from the bounding set needs CAP_SETPCAP, so while still root.
+	if opts.dropBset {
+		if err = dropBounding(caps); err != nil {
+			errorExit(1, err, fmt.Sprintf("su: %v", err))
+		}
+	}
+
+	// The groups of the caller are kept unless WHO sets them, see
+	// extractUidGids.
+	if len(gids) > 0 {
+		if err = syscall.Setgroups(gids); err != nil {
+			errorExit(1, err, "setgroups failed")
 		}
-		args = args[1:]
 	}
 
 	if err = syscall.Setgid(gid); err != nil {
@@ -206,31 +395,32 @@ func main() {
 		errorExit(1, err, "setuid failed")
 	}
 
-	// Reset parts of the environment.
-	if !path {
-		_ = os.Setenv("PATH", defPath())
-	}
-	_ = os.Unsetenv("IFS")
-	if pw := user.Getpwuid(uint32(uid)); pw != nil {
-		_ = os.Setenv("LOGNAME", pw.Name)
-		_ = os.Setenv("USER", pw.Name)
-	} else {
-		_ = os.Unsetenv("LOGNAME")
-		_ = os.Unsetenv("USER")
+	if keepCaps {
+		if err = setCapabilities(caps); errors.Is(err, errNoAmbient) {
+			_, _ = fmt.Fprintf(os.Stderr, "su: warning: %v, the capabilities are only inheritable\n", err)
+		} else if err != nil {
+			errorExit(1, err, fmt.Sprintf("su: %v", err))
+		}
 	}
 
-	// Set up the arguments for exec.
-	var execArgs = make([]string, 0, len(args)+1)
-	for _, arg := range args {
-		execArgs = append(execArgs, arg)
+	// A login shell starts in the home directory, the command of a client
+	// of the daemon in the directory of the client, as the target user.
+	if opts.login && pw != nil {
+		_ = os.Chdir(pw.Dir)
+	} else if dir != "" {
+		_ = os.Chdir(dir)
 	}
 
-	// Default to the standard shell.
-	if len(execArgs) == 0 {
-		execArgs = append(execArgs, "/system/bin/sh")
+	// Stay around to relay the PTY of the command.
+	if opts.pty {
+		status, err := runPTY(path, execArgs, env, os.Stdin, os.Stdout)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "su: %v\n", err)
+		}
+		os.Exit(status)
 	}
 
-	if err = syscall.Exec(execArgs[0], execArgs, os.Environ()); err != nil {
-		errorExit(1, err, fmt.Sprintf("failed to exec %s", execArgs[0]))
+	if err = syscall.Exec(path, execArgs, env); err != nil {
+		errorExit(1, err, fmt.Sprintf("failed to exec %s", path))
 	}
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zooyer/android/user"
+)
+
+func TestGroupList(t *testing.T) {
+	var oldRoot, oldNames = user.RootDir, user.LookupPackageNames
+	user.RootDir, user.LookupPackageNames = "../user/testdata/root", true
+	t.Cleanup(func() { user.RootDir, user.LookupPackageNames = oldRoot, oldNames })
+
+	var tests = []struct {
+		name string
+		gid  int
+		gids []int
+	}{
+		{"system_tvbox", 6000, []int{6000, 6500}},
+		{"com.dangbei.tvlauncher", 10048, []int{10048, 3003, 3002}},
+		{"com.example.debug", 10102, []int{10102}},
+		{"10048", 10048, nil},
+	}
+
+	for _, test := range tests {
+		if gids := groupList(test.name, test.gid); fmt.Sprint(gids) != fmt.Sprint(test.gids) {
+			t.Fatalf("groupList(%q) = %v, want %v", test.name, gids, test.gids)
+		}
+	}
+}
+
+func TestExtractUidGids(t *testing.T) {
+	var oldRoot, oldNames = user.RootDir, user.LookupPackageNames
+	user.RootDir, user.LookupPackageNames = "../user/testdata/root", true
+	t.Cleanup(func() { user.RootDir, user.LookupPackageNames = oldRoot, oldNames })
+
+	var tests = []struct {
+		ids      string
+		uid, gid int
+		gids     []int
+	}{
+		{"root", 0, 0, nil},
+		{"0,0", 0, 0, nil},
+		{"0,0,inet,3002", 0, 0, []int{3003, 3002}},
+		{"2000", 2000, 2000, nil},
+		{"system_tvbox", 6000, 6000, []int{6000, 6500}},
+		{"system_tvbox,1000", 6000, 1000, []int{1000, 6000, 6500}},
+		{"com.dangbei.tvlauncher", 10048, 10048, []int{10048, 3003, 3002}},
+	}
+
+	for _, test := range tests {
+		uid, gid, gids := extractUidGids(test.ids)
+		if uid != test.uid || gid != test.gid || fmt.Sprint(gids) != fmt.Sprint(test.gids) {
+			t.Fatalf("extractUidGids(%q) = %d, %d, %v", test.ids, uid, gid, gids)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: options.go
+ * @Package: main
+ * @Version: 1.0.0
+ * @Date: 2026/10/19 14:30
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zooyer/android/user"
+)
+
+const defaultShell = "/system/bin/sh"
+
+// options are the command line options of su.
+type options struct {
+	help     bool     // -h, --help
+	path     bool     // --path: inherit the PATH of the caller
+	login    bool     // -, -l, --login: start a login shell with a clean environment
+	preserve bool     // -m, -p, --preserve-environment: keep the whole environment
+	shell    string   // -s, --shell: the shell to run
+	command  string   // -c, --command: the command run by the shell
+	hasCmd   bool     // -c was given
+	pty      bool     // --pty: run the command in a pseudo-terminal
+	daemon   bool     // --daemon: serve the su clients
+	caps     string   // --caps: the capabilities kept by the target user
+	dropBset bool     // --drop-bounding: drop the other capabilities from the bounding set
+	context  string   // --context: the SELinux context of the command
+	who      string   // WHO, "" for root
+	args     []string // COMMAND and its arguments
+}
+
+var errMissingArgument = errors.New("option requires an argument")
+
+// splitShort splits the short options combined in arg, as in -lm, into
+// separate arguments. The rest of arg after -s or -c is their value, as
+// getopt takes it.
+func splitShort(arg string) []string {
+	if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' {
+		return []string{arg}
+	}
+
+	var split []string
+	for i := 1; i < len(arg); i++ {
+		split = append(split, "-"+arg[i:i+1])
+		if (arg[i] == 's' || arg[i] == 'c') && i+1 < len(arg) {
+			return append(split, arg[i+1:])
+		}
+	}
+
+	return split
+}
+
+// parseOptions parses the command line of su. The options come before WHO,
+// -c is also accepted right after WHO, the way Magisk and toybox su do.
+func parseOptions(args []string) (opts options, err error) {
+	// value returns the argument of the option name, given as --name=value or
+	// as the next argument.
+	var value = func(name string, inline *string) (string, error) {
+		if inline != nil {
+			return *inline, nil
+		}
+		if len(args) < 2 {
+			return "", fmt.Errorf("%w: %s", errMissingArgument, name)
+		}
+		args = args[1:]
+		return args[0], nil
+	}
+
+	var parseOption = func() (ok bool, err error) {
+		var name, inline = args[0], (*string)(nil)
+		if index := strings.IndexByte(name, '='); strings.HasPrefix(name, "--") && index > 0 {
+			var v = name[index+1:]
+			name, inline = name[:index], &v
+		}
+
+		switch name {
+		case "-h", "--help":
+			opts.help = true
+		case "--path":
+			opts.path = true
+		case "--pty":
+			opts.pty = true
+		case "--daemon":
+			opts.daemon = true
+		case "--caps":
+			opts.caps, err = value(name, inline)
+		case "--drop-bounding":
+			opts.dropBset = true
+		case "--context":
+			opts.context, err = value(name, inline)
+		case "-", "-l", "--login":
+			opts.login = true
+		case "-m", "-p", "--preserve-environment":
+			opts.preserve = true
+		case "-s", "--shell":
+			opts.shell, err = value(name, inline)
+		case "-c", "--command":
+			opts.command, err = value(name, inline)
+			opts.hasCmd = true
+		case "--":
+			args = args[1:]
+			return false, nil
+		default:
+			if len(name) > 1 && name[0] == '-' {
+				return false, fmt.Errorf("unknown option '%s'", name)
+			}
+			return false, nil
+		}
+
+		args = args[1:]
+		return err == nil, err
+	}
+
+	for len(args) > 0 {
+		if split := splitShort(args[0]); len(split) > 1 {
+			args = append(split, args[1:]...)
+		}
+
+		ok, err := parseOption()
+		if err != nil {
+			return opts, err
+		}
+		if !ok {
+			break
+		}
+	}
+
+	if len(args) > 0 {
+		opts.who, args = args[0], args[1:]
+	}
+
+	// su WHO -c CMD
+	if len(args) > 0 && (args[0] == "-c" || args[0] == "--command") {
+		if len(args) < 2 {
+			return opts, fmt.Errorf("%w: %s", errMissingArgument, args[0])
+		}
+		opts.command, opts.hasCmd, args = args[1], true, args[2:]
+	}
+
+	opts.args = args
+
+	return
+}
+
+// lookPath resolves a bare command name through the directories of path,
+// like the shell does. Names with a '/' are returned as is.
+func lookPath(name, path string) (string, error) {
+	if strings.Contains(name, "/") {
+		return name, nil
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+
+		var file = filepath.Join(dir, name)
+		if stat, err := os.Stat(file); err == nil && stat.Mode().IsRegular() && stat.Mode()&0111 != 0 {
+			return file, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: command not found", name)
+}
+
+// targetShell returns the shell of the target user pw.
+func (opts *options) targetShell(pw *user.Passwd) string {
+	switch {
+	case opts.shell != "":
+		return opts.shell
+	case pw != nil && pw.Shell != "":
+		return pw.Shell
+	}
+
+	return defaultShell
+}
+
+// execArgs returns the path and the arguments to exec for the target user
+// pw. COMMAND is resolved through the PATH of env.
+func (opts *options) execArgs(pw *user.Passwd, env []string) (path string, argv []string, err error) {
+	var shell = opts.targetShell(pw)
+
+	switch {
+	case opts.hasCmd:
+		argv = append([]string{shell, "-c", opts.command}, opts.args...)
+	case len(opts.args) > 0:
+		argv = append([]string(nil), opts.args...)
+	default:
+		argv = []string{shell}
+	}
+
+	if path, err = lookPath(argv[0], getenv(env, "PATH")); err != nil {
+		return
+	}
+	argv[0] = path
+
+	// A login shell is told by the '-' in front of its name.
+	if opts.login && !opts.hasCmd && len(opts.args) == 0 {
+		argv[0] = "-" + filepath.Base(path)
+	}
+
+	return
+}
+
+// getenv returns the value of key in env.
+func getenv(env []string, key string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(env[i], key+"=") {
+			return env[i][len(key)+1:]
+		}
+	}
+
+	return ""
+}
+
+// setenv sets key to value in env, an empty value unsets it.
+func setenv(env []string, key, value string) []string {
+	var result = make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, key+"=") {
+			result = append(result, kv)
+		}
+	}
+
+	if value != "" {
+		result = append(result, key+"="+value)
+	}
+
+	return result
+}
+
+// environ returns the environment of the command run as the target user
+// pw, made from the environment env of the caller.
+func (opts *options) environ(pw *user.Passwd, env []string) []string {
+	var name string
+	if pw != nil {
+		name = pw.Name
+	}
+
+	switch {
+	case opts.login:
+		// A clean environment, keeping the terminal type.
+		var clean []string
+		clean = setenv(clean, "TERM", getenv(env, "TERM"))
+		clean = setenv(clean, "PATH", defPath())
+		clean = setenv(clean, "SHELL", opts.targetShell(pw))
+		clean = setenv(clean, "USER", name)
+		clean = setenv(clean, "LOGNAME", name)
+		if pw != nil {
+			clean = setenv(clean, "HOME", pw.Dir)
+		}
+		return clean
+
+	case opts.preserve:
+		return env
+	}
+
+	// Reset parts of the environment.
+	if !opts.path {
+		env = setenv(env, "PATH", defPath())
+	}
+	env = setenv(env, "IFS", "")
+	env = setenv(env, "LOGNAME", name)
+	env = setenv(env, "USER", name)
+
+	return env
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zooyer/android/user"
+)
+
+func TestParseOptions(t *testing.T) {
+	var tests = []struct {
+		args []string
+		opts string
+	}{
+		{nil, "{help:false path:false login:false preserve:false shell: command: hasCmd:false pty:false daemon:false caps: dropBset:false context: who: args:[]}"},
+		{[]string{"--path", "0", "ls", "-l"}, "{help:false path:true login:false preserve:false shell: command: hasCmd:false pty:false daemon:false caps: dropBset:false context: who:0 args:[ls -l]}"},
+		{[]string{"-", "shell"}, "{help:false path:false login:true preserve:false shell: command: hasCmd:false pty:false daemon:false caps: dropBset:false context: who:shell args:[]}"},
+		{[]string{"-l", "-s", "/bin/bash", "-c", "id -u", "system"}, "{help:false path:false login:true preserve:false shell:/bin/bash command:id -u hasCmd:true pty:false daemon:false caps: dropBset:false context: who:system args:[]}"},
+		{[]string{"-p", "--shell=sh", "--command=id", "2000"}, "{help:false path:false login:false preserve:true shell:sh command:id hasCmd:true pty:false daemon:false caps: dropBset:false context: who:2000 args:[]}"},
+		{[]string{"-m", "0", "-c", "echo $0", "arg"}, "{help:false path:false login:false preserve:true shell: command:echo $0 hasCmd:true pty:false daemon:false caps: dropBset:false context: who:0 args:[arg]}"},
+		{[]string{"--", "-1"}, "{help:false path:false login:false preserve:false shell: command: hasCmd:false pty:false daemon:false caps: dropBset:false context: who:-1 args:[]}"},
+		{[]string{"--pty", "-c", "sh"}, "{help:false path:false login:false preserve:false shell: command:sh hasCmd:true pty:true daemon:false caps: dropBset:false context: who: args:[]}"},
+		{[]string{"--caps=CAP_NET_ADMIN,SYS_NICE", "--drop-bounding", "system"}, "{help:false path:false login:false preserve:false shell: command: hasCmd:false pty:false daemon:false caps:CAP_NET_ADMIN,SYS_NICE dropBset:true context: who:system args:[]}"},
+		{[]string{"--context", "u:r:su:s0", "0", "id"}, "{help:false path:false login:false preserve:false shell: command: hasCmd:false pty:false daemon:false caps: dropBset:false context:u:r:su:s0 who:0 args:[id]}"},
+		{[]string{"-lm", "-cid", "0"}, "{help:false path:false login:true preserve:true shell: command:id hasCmd:true pty:false daemon:false caps: dropBset:false context: who:0 args:[]}"},
+		{[]string{"-ps", "/bin/bash", "2000"}, "{help:false path:false login:false preserve:true shell:/bin/bash command: hasCmd:false pty:false daemon:false caps: dropBset:false context: who:2000 args:[]}"},
+		{[]string{"-h"}, "{help:true path:false login:false preserve:false shell: command: hasCmd:false pty:false daemon:false caps: dropBset:false context: who: args:[]}"},
+	}
+
+	for _, test := range tests {
+		opts, err := parseOptions(test.args)
+		if err != nil {
+			t.Fatalf("parseOptions(%q) = %v", test.args, err)
+		}
+		if got := fmt.Sprintf("%+v", opts); got != test.opts {
+			t.Fatalf("parseOptions(%q) = %s, want %s", test.args, got, test.opts)
+		}
+	}
+
+	for _, args := range [][]string{{"-c"}, {"-s"}, {"--caps"}, {"--context"}, {"0", "-c"}, {"-x", "0"}, {"-lx"}, {"-ls"}} {
+		if opts, err := parseOptions(args); err == nil {
+			t.Fatalf("parseOptions(%q) = %+v", args, opts)
+		}
+	}
+	if _, err := parseOptions([]string{"-c"}); !errors.Is(err, errMissingArgument) {
+		t.Fatalf("parseOptions(-c) = %v", err)
+	}
+}
+
+func TestExecArgs(t *testing.T) {
+	var dir = t.TempDir()
+	for _, name := range []string{"sh", "ls"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		pw  = &user.Passwd{Name: "shell", UID: 2000, GID: 2000, Dir: "/data/local/tmp", Shell: filepath.Join(dir, "sh")}
+		env = []string{"PATH=" + dir}
+		sh  = filepath.Join(dir, "sh")
+		ls  = filepath.Join(dir, "ls")
+	)
+
+	var tests = []struct {
+		opts options
+		path string
+		argv string
+	}{
+		{options{}, sh, fmt.Sprint([]string{sh})},
+		{options{login: true}, sh, "[-sh]"},
+		{options{args: []string{"ls", "-l"}}, ls, fmt.Sprint([]string{ls, "-l"})},
+		{options{args: []string{"/system/bin/ls"}}, "/system/bin/ls", "[/system/bin/ls]"},
+		{options{shell: "sh", command: "id", hasCmd: true, args: []string{"a"}}, sh, fmt.Sprint([]string{sh, "-c", "id", "a"})},
+	}
+
+	for _, test := range tests {
+		path, argv, err := test.opts.execArgs(pw, env)
+		if err != nil || path != test.path || fmt.Sprint(argv) != test.argv {
+			t.Fatalf("execArgs(%+v) = %s, %q, %v", test.opts, path, argv, err)
+		}
+	}
+
+	// Not executable, or not found.
+	for _, name := range []string{"data", "missing"} {
+		if _, _, err := (&options{args: []string{name}}).execArgs(pw, env); err == nil {
+			t.Fatalf("execArgs(%s) succeeded", name)
+		}
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	var (
+		pw  = &user.Passwd{Name: "shell", UID: 2000, GID: 2000, Dir: "/data/local/tmp", Shell: "/system/bin/sh"}
+		env = []string{"PATH=/caller/bin", "TERM=xterm", "IFS=x", "USER=root", "FOO=bar"}
+	)
+
+	var login = (&options{login: true}).environ(pw, env)
+	if getenv(login, "HOME") != "/data/local/tmp" || getenv(login, "SHELL") != "/system/bin/sh" || getenv(login, "USER") != "shell" ||
+		getenv(login, "TERM") != "xterm" || getenv(login, "FOO") != "" || getenv(login, "PATH") == "/caller/bin" {
+		t.Fatalf("login environment = %q", login)
+	}
+
+	if preserved := (&options{preserve: true}).environ(pw, env); fmt.Sprint(preserved) != fmt.Sprint(env) {
+		t.Fatalf("preserved environment = %q", preserved)
+	}
+
+	var reset = (&options{path: true}).environ(pw, env)
+	if getenv(reset, "PATH") != "/caller/bin" || getenv(reset, "IFS") != "" || getenv(reset, "USER") != "shell" || getenv(reset, "FOO") != "bar" {
+		t.Fatalf("environment = %q", reset)
+	}
+}
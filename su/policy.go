@@ -0,0 +1,269 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: policy.go
+ * @Package: main
+ * @Version: 1.0.0
+ * @Date: 2026/10/19 10:20
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/zooyer/android/user"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule allows callers to switch to targets and run commands. An empty list
+// matches anything, except for callers and packages, one of which must match.
+type Rule struct {
+	Callers  []string `yaml:"callers" json:"callers"`   // caller uids or user names
+	Packages []string `yaml:"packages" json:"packages"` // package names of the caller apps, in any user
+	Targets  []string `yaml:"targets" json:"targets"`   // target uids or user names
+	Commands []string `yaml:"commands" json:"commands"` // absolute command paths, shell patterns allowed
+	Caps     []string `yaml:"caps" json:"caps"`         // capabilities the target may keep, all if it may be root
+	Contexts []string `yaml:"contexts" json:"contexts"` // SELinux contexts the command may run in, shell patterns allowed
+}
+
+// request is a switch su is asked for.
+type request struct {
+	caller, target uint32
+	command        string
+	caps           uint64 // capabilities kept by the target
+	context        string // SELinux context of the command, "" to keep the one of su
+}
+
+// Policy is the allowlist of su, the first matching rule allows the switch.
+type Policy struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+	Audit Audit  `yaml:"audit" json:"audit"` // where the invocations are recorded
+}
+
+// The policy of AOSP su: root and shell only, in any domain.
+var defaultPolicy = Policy{
+	Rules: []Rule{{Callers: []string{"root", "shell"}, Contexts: []string{"u:r:*"}}},
+}
+
+// The policy files looked up next to the su executable. JSON is valid YAML.
+var policyFiles = []string{"su.yaml", "su.json"}
+
+// The uid which must own the policy files and their directory.
+var policyOwner uint32 = 0
+
+// checkOwner fails unless path is owned by policyOwner, writable by it only,
+// and not a symbolic link, so nobody else can change the policy.
+func checkOwner(path string) error {
+	stat, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	if stat.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%s: is a symbolic link", path)
+	}
+	if sys, ok := stat.Sys().(*syscall.Stat_t); !ok || sys.Uid != policyOwner {
+		return fmt.Errorf("%s: not owned by uid %d", path, policyOwner)
+	}
+	if stat.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("%s: writable by group or others", path)
+	}
+
+	return nil
+}
+
+// loadPolicy reads the first policy file found in dir, or returns the
+// default policy if there's none. A broken policy file is an error, so su
+// never falls back to a more permissive policy, and so is a file or a dir
+// someone other than root could have changed.
+func loadPolicy(dir string) (policy *Policy, err error) {
+	// Never look up the policy relative to the working directory.
+	if !filepath.IsAbs(dir) {
+		return &defaultPolicy, nil
+	}
+
+	for _, name := range policyFiles {
+		var file = filepath.Join(dir, name)
+		if _, err := os.Lstat(file); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		for _, path := range []string{dir, file} {
+			if err = checkOwner(path); err != nil {
+				return nil, err
+			}
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		policy = new(Policy)
+		if err = yaml.Unmarshal(data, policy); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		return policy, nil
+	}
+
+	return &defaultPolicy, nil
+}
+
+// resolveID translates a uid or a user name of the policy into a uid.
+func resolveID(name string) (id uint32, ok bool) {
+	if num, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(num), true
+	}
+
+	if pw := user.Getpwnam(name); pw != nil {
+		return pw.UID, true
+	}
+
+	return
+}
+
+func matchID(names []string, id uint32) bool {
+	for _, name := range names {
+		if uid, ok := resolveID(name); ok && uid == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchPackage(names []string, uid uint32) bool {
+	if len(names) == 0 {
+		return false
+	}
+
+	for _, pkg := range user.LookupPackagesByUID(uid) {
+		for _, name := range names {
+			if pkg.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchCommand matches the absolute path command against the patterns.
+// Only absolute patterns match, a base name would match a copy of the
+// command anywhere. Commands run by a shell only match if the shell does.
+func matchCommand(patterns []string, command string) bool {
+	if !filepath.IsAbs(command) {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, path.Clean(command)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restrictEnv returns env with the default PATH and without the variables
+// of the dynamic linker, so the commands a rule allows can't be hijacked
+// through the environment of the caller.
+func restrictEnv(env []string) []string {
+	var result = make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, "LD_") && !strings.HasPrefix(kv, "PATH=") {
+			result = append(result, kv)
+		}
+	}
+
+	return append(result, "PATH="+defPath())
+}
+
+// allowRoot reports whether the rule allows root as target, which has all
+// the capabilities anyway.
+func (r *Rule) allowRoot() bool {
+	return len(r.Targets) == 0 || matchID(r.Targets, user.AidRoot)
+}
+
+// allowCaps reports whether the target may keep the capabilities caps.
+func (r *Rule) allowCaps(caps uint64) bool {
+	if caps == 0 || r.allowRoot() {
+		return true
+	}
+
+	mask, err := user.ParseCapabilities(strings.Join(r.Caps, ","))
+	return err == nil && caps&^mask == 0
+}
+
+// allowContext reports whether the command may run in the SELinux context.
+// Unlike the capabilities, root as target doesn't allow any context.
+func (r *Rule) allowContext(context string) bool {
+	if context == "" {
+		return true
+	}
+
+	for _, pattern := range r.Contexts {
+		if ok, _ := path.Match(pattern, context); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) allow(req request) bool {
+	if !matchID(r.Callers, req.caller) && !matchPackage(r.Packages, req.caller) {
+		return false
+	}
+
+	if len(r.Targets) > 0 && !matchID(r.Targets, req.target) {
+		return false
+	}
+
+	if len(r.Commands) > 0 && !matchCommand(r.Commands, req.command) {
+		return false
+	}
+
+	return r.allowCaps(req.caps) && r.allowContext(req.context)
+}
+
+// allowCaller reports whether any rule allows the caller uid, before su
+// resolves anything on its behalf.
+func (p *Policy) allowCaller(caller uint32) bool {
+	for i := range p.Rules {
+		if matchID(p.Rules[i].Callers, caller) || matchPackage(p.Rules[i].Packages, caller) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Match returns the first rule allowing req, nil if there's none.
+func (p *Policy) Match(req request) *Rule {
+	for i := range p.Rules {
+		if p.Rules[i].allow(req) {
+			return &p.Rules[i]
+		}
+	}
+
+	return nil
+}
+
+// Allow reports whether the caller uid may run command as the target uid.
+func (p *Policy) Allow(caller, target uint32, command string) bool {
+	return p.Match(request{caller: caller, target: target, command: command}) != nil
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zooyer/android/user"
+	"gopkg.in/yaml.v3"
+)
+
+// withPolicyOwner trusts the policy files of the user running the tests.
+func withPolicyOwner(t *testing.T) {
+	var old = policyOwner
+	policyOwner = uint32(os.Getuid())
+	t.Cleanup(func() { policyOwner = old })
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	policy, err := loadPolicy(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !policy.Allow(user.AidRoot, user.AidSystem, "/system/bin/sh") || !policy.Allow(user.AidShell, 0, "/system/bin/sh") {
+		t.Fatal("root and shell denied")
+	}
+	if policy.Allow(10048, 0, "/system/bin/sh") || policy.Allow(user.AidSystem, 0, "/system/bin/sh") {
+		t.Fatal("app allowed")
+	}
+
+	// The shipped su.yaml keeps the default rule.
+	data, err := os.ReadFile("su.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shipped Policy
+	if err = yaml.Unmarshal(data, &shipped); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, policy := range []*Policy{policy, &shipped} {
+		for _, caller := range []uint32{user.AidRoot, user.AidShell} {
+			if policy.Match(request{caller: caller, target: 0, command: "/system/bin/sh", context: "u:r:su:s0"}) == nil {
+				t.Fatalf("--context denied for %d", caller)
+			}
+		}
+	}
+
+	// A relative directory is never trusted.
+	if policy, err = loadPolicy(""); err != nil || policy != &defaultPolicy {
+		t.Fatalf("loadPolicy(\"\") = %v, %v", policy, err)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	var old = user.RootDir
+	user.RootDir = "../user/testdata/root"
+	t.Cleanup(func() { user.RootDir = old })
+	withPolicyOwner(t)
+
+	var dir = t.TempDir()
+	var config = `
+rules:
+  - packages: [com.example.debug]
+    targets: [root]
+  - callers: [u0_a48, "10049"]
+    targets: [system]
+    commands: [/system/bin/logcat, /system/bin/dumpsys, "/data/local/tmp/*"]
+  - callers: ["10050"]
+    commands: [logcat, sh]
+`
+	if err := os.WriteFile(filepath.Join(dir, "su.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := loadPolicy(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		caller, target uint32
+		command        string
+		allow          bool
+	}{
+		{10102, 0, "/system/bin/sh", true},
+		{1010102, 0, "/system/bin/sh", true},
+		{10102, user.AidSystem, "/system/bin/sh", false},
+		{10048, user.AidSystem, "/system/bin/logcat", true},
+		{10049, user.AidSystem, "/system/bin/dumpsys", true},
+		{10048, user.AidSystem, "/data/local/tmp/tool", true},
+		{10048, user.AidSystem, "/data/local/tmp/dir/tool", false},
+		{10048, user.AidSystem, "/vendor/bin/dumpsys", false},
+		{10048, 0, "/system/bin/logcat", false},
+		{10048, user.AidSystem, "/data/local/tmp/../../system/bin/sh", false},
+		{10048, user.AidSystem, "logcat", false},
+		{user.AidShell, 0, "/system/bin/sh", false},
+		{10050, 0, "/system/bin/logcat", false},
+		{10050, 0, "/data/local/tmp/logcat", false},
+		{10050, 0, "/system/bin/sh", false},
+	}
+
+	for _, test := range tests {
+		if allow := policy.Allow(test.caller, test.target, test.command); allow != test.allow {
+			t.Fatalf("Allow(%d, %d, %s) = %v", test.caller, test.target, test.command, allow)
+		}
+	}
+
+	// The callers no rule lists are turned away before anything else.
+	for caller, allow := range map[uint32]bool{10102: true, 10048: true, 10050: true, user.AidShell: false, 10051: false} {
+		if got := policy.allowCaller(caller); got != allow {
+			t.Fatalf("allowCaller(%d) = %v", caller, got)
+		}
+	}
+
+	// A broken policy file is an error, not the default policy.
+	if err = os.WriteFile(filepath.Join(dir, "su.yaml"), []byte("rules: {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = loadPolicy(dir); err == nil {
+		t.Fatal("loadPolicy of a broken file succeeded")
+	}
+}
+
+func TestPolicyJSON(t *testing.T) {
+	withPolicyOwner(t)
+
+	var dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "su.json"), []byte(`{"rules": [{"callers": ["system"]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := loadPolicy(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !policy.Allow(user.AidSystem, 0, "/system/bin/sh") || policy.Allow(user.AidShell, 0, "/system/bin/sh") {
+		t.Fatalf("policy = %+v", policy)
+	}
+}
+
+func TestPolicyOwner(t *testing.T) {
+	withPolicyOwner(t)
+
+	var config = []byte(`{"rules": [{"callers": ["system"]}]}`)
+
+	var write = func(t *testing.T, dir string, mode os.FileMode) {
+		var file = filepath.Join(dir, "su.yaml")
+		if err := os.WriteFile(file, config, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(file, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var dir = t.TempDir()
+	write(t, dir, 0600)
+	if _, err := loadPolicy(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writable by others.
+	write(t, dir, 0666)
+	if _, err := loadPolicy(dir); err == nil {
+		t.Fatal("loadPolicy of a world writable file succeeded")
+	}
+
+	// In a directory writable by others.
+	dir = t.TempDir()
+	write(t, dir, 0600)
+	if err := os.Chmod(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPolicy(dir); err == nil {
+		t.Fatal("loadPolicy in a world writable directory succeeded")
+	}
+
+	// A symbolic link, even to a good file.
+	var other = t.TempDir()
+	write(t, other, 0600)
+	dir = t.TempDir()
+	if err := os.Symlink(filepath.Join(other, "su.yaml"), filepath.Join(dir, "su.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPolicy(dir); err == nil {
+		t.Fatal("loadPolicy of a symbolic link succeeded")
+	}
+
+	// Owned by another user.
+	policyOwner++
+	if _, err := loadPolicy(other); err == nil {
+		t.Fatal("loadPolicy of a file owned by another user succeeded")
+	}
+}
+
+func TestRestrictEnv(t *testing.T) {
+	var env = restrictEnv([]string{"PATH=/data/local/tmp", "LD_PRELOAD=/data/local/tmp/hook.so", "LD_LIBRARY_PATH=/data", "TERM=xterm"})
+	if getenv(env, "PATH") != defPath() || getenv(env, "LD_PRELOAD") != "" || getenv(env, "LD_LIBRARY_PATH") != "" ||
+		getenv(env, "TERM") != "xterm" || len(env) != 2 {
+		t.Fatalf("restrictEnv() = %q", env)
+	}
+}
+
+func TestPolicyCaps(t *testing.T) {
+	var policy = &Policy{Rules: []Rule{
+		{Callers: []string{"10048"}, Targets: []string{"shell"}, Caps: []string{"CAP_NET_ADMIN", "SYS_NICE"}},
+		{Callers: []string{"10049"}, Targets: []string{"shell"}},
+		{Callers: []string{"10050"}, Targets: []string{"shell", "root"}},
+	}}
+
+	var netAdmin, sysAdmin = user.CapMaskLong(user.CapNetAdmin), user.CapMaskLong(user.CapSysAdmin)
+
+	var tests = []struct {
+		caller uint32
+		caps   uint64
+		allow  bool
+	}{
+		{10048, 0, true},
+		{10048, netAdmin | user.CapMaskLong(user.CapSysNice), true},
+		{10048, netAdmin | sysAdmin, false},
+		{10049, 0, true},
+		{10049, netAdmin, false},
+		{10050, sysAdmin, true},
+	}
+
+	for _, test := range tests {
+		var req = request{caller: test.caller, target: user.AidShell, command: "/system/bin/sh", caps: test.caps}
+		if allow := policy.Match(req) != nil; allow != test.allow {
+			t.Fatalf("Match(%d, %s) = %v", test.caller, user.FormatCapabilities(test.caps), allow)
+		}
+	}
+}
+
+func TestPolicyContexts(t *testing.T) {
+	var policy = &Policy{Rules: []Rule{
+		{Callers: []string{"10048"}, Targets: []string{"shell"}, Contexts: []string{"u:r:shell:s0", "u:r:untrusted_app:s0:*"}},
+		{Callers: []string{"10049"}},
+	}}
+
+	var tests = []struct {
+		caller  uint32
+		context string
+		allow   bool
+	}{
+		{10048, "", true},
+		{10048, "u:r:shell:s0", true},
+		{10048, "u:r:untrusted_app:s0:c512,c768", true},
+		{10048, "u:r:su:s0", false},
+		{10049, "", true},
+		{10049, "u:r:kernel:s0", false},
+	}
+
+	for _, test := range tests {
+		var req = request{caller: test.caller, target: user.AidShell, command: "/system/bin/sh", context: test.context}
+		if allow := policy.Match(req) != nil; allow != test.allow {
+			t.Fatalf("Match(%d, %q) = %v", test.caller, test.context, allow)
+		}
+	}
+}
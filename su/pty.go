@@ -0,0 +1,240 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: pty.go
+ * @Package: main
+ * @Version: 1.0.0
+ * @Date: 2026/10/19 16:10
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// The control character ending the input of a terminal, VEOF.
+const ctrlD = 0x04
+
+// The signals su passes on to the command running in the PTY.
+var ptySignals = []os.Signal{syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGUSR1, syscall.SIGUSR2}
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// openPTY allocates a pseudo-terminal, the way posix_openpt, unlockpt and
+// ptsname do.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = master.Close()
+		}
+	}()
+
+	var unlock int32
+	if err = ioctl(master.Fd(), syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		return nil, nil, fmt.Errorf("unlockpt: %w", err)
+	}
+
+	var n uint32
+	if err = ioctl(master.Fd(), syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		return nil, nil, fmt.Errorf("ptsname: %w", err)
+	}
+
+	if slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0); err != nil {
+		return nil, nil, err
+	}
+
+	return
+}
+
+// terminal returns the file of r if it's a terminal.
+func terminal(r io.Reader) (file *os.File, ok bool) {
+	if file, ok = r.(*os.File); !ok {
+		return
+	}
+
+	var termios syscall.Termios
+	return file, ioctl(file.Fd(), syscall.TCGETS, unsafe.Pointer(&termios)) == nil
+}
+
+// copyWinsize sets the window size of the terminal dst to the one of src.
+func copyWinsize(dst, src *os.File) error {
+	var ws struct{ row, col, xpixel, ypixel uint16 }
+	if err := ioctl(src.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return err
+	}
+
+	return ioctl(dst.Fd(), syscall.TIOCSWINSZ, unsafe.Pointer(&ws))
+}
+
+// makeRaw puts the terminal file in raw mode as cfmakeraw does, restore
+// brings back the previous mode.
+func makeRaw(file *os.File) (restore func(), err error) {
+	var old syscall.Termios
+	if err = ioctl(file.Fd(), syscall.TCGETS, unsafe.Pointer(&old)); err != nil {
+		return
+	}
+
+	var raw = old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err = ioctl(file.Fd(), syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		return
+	}
+
+	return func() { _ = ioctl(file.Fd(), syscall.TCSETS, unsafe.Pointer(&old)) }, nil
+}
+
+// disableEcho stops the terminal file from echoing its input.
+func disableEcho(file *os.File) error {
+	var termios syscall.Termios
+	if err := ioctl(file.Fd(), syscall.TCGETS, unsafe.Pointer(&termios)); err != nil {
+		return err
+	}
+
+	termios.Lflag &^= syscall.ECHO
+	return ioctl(file.Fd(), syscall.TCSETS, unsafe.Pointer(&termios))
+}
+
+// copyInput copies stdin to the PTY master. At the end of stdin, it ends the
+// input of the PTY with ^D, after one more ^D if a line is pending.
+func copyInput(master io.Writer, stdin io.Reader) {
+	var (
+		buf       = make([]byte, 32*1024)
+		last byte = '\n'
+	)
+
+	for {
+		n, err := stdin.Read(buf)
+		if n > 0 {
+			if _, err := master.Write(buf[:n]); err != nil {
+				return
+			}
+			last = buf[n-1]
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	var eof = []byte{ctrlD}
+	if last != '\n' {
+		eof = append(eof, ctrlD)
+	}
+	_, _ = master.Write(eof)
+}
+
+// copyOutput copies the PTY master to stdout until every process closed the
+// PTY, which reads of the master report as EIO.
+func copyOutput(stdout io.Writer, master io.Reader) error {
+	_, err := io.Copy(stdout, master)
+	if errors.Is(err, syscall.EIO) {
+		return nil
+	}
+
+	return err
+}
+
+// exitStatus returns the exit status of the shell for state: the exit code,
+// or 128 plus the signal that killed the process.
+func exitStatus(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return state.ExitCode()
+}
+
+// runPTY runs path with argv and env in a new session, with a PTY as its
+// controlling terminal. It relays stdin and stdout, which needn't be
+// terminals, and returns the exit status of the command.
+func runPTY(path string, argv, env []string, stdin io.Reader, stdout io.Writer) (status int, err error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return 1, fmt.Errorf("open pty: %w", err)
+	}
+	defer master.Close()
+
+	// Take over the terminal of the caller, or keep the input from being
+	// echoed into the output of the command.
+	if tty, ok := terminal(stdin); ok {
+		_ = copyWinsize(slave, tty)
+		if restore, err := makeRaw(tty); err == nil {
+			defer restore()
+		}
+	} else if err = disableEcho(slave); err != nil {
+		_ = slave.Close()
+		return 1, fmt.Errorf("pty: %w", err)
+	}
+
+	var cmd = &exec.Cmd{
+		Path:   path,
+		Args:   argv,
+		Env:    env,
+		Stdin:  slave,
+		Stdout: slave,
+		Stderr: slave,
+		SysProcAttr: &syscall.SysProcAttr{
+			Setsid:  true,
+			Setctty: true,
+			Ctty:    0, // the stdin of the command
+		},
+	}
+
+	var signals = make(chan os.Signal, 8)
+	signal.Notify(signals, append(ptySignals, syscall.SIGWINCH)...)
+	defer signal.Stop(signals)
+
+	err = cmd.Start()
+	_ = slave.Close()
+	if err != nil {
+		return 1, err
+	}
+
+	var done = make(chan error, 1)
+	go func() { done <- copyOutput(stdout, master) }()
+	go copyInput(master, stdin)
+
+	var outputErr error
+	for running := true; running; {
+		select {
+		case sig := <-signals:
+			if sig != syscall.SIGWINCH {
+				_ = cmd.Process.Signal(sig)
+			} else if tty, ok := terminal(stdin); ok {
+				_ = copyWinsize(master, tty)
+			}
+		case outputErr = <-done:
+			running = false
+		}
+	}
+
+	if err = cmd.Wait(); cmd.ProcessState == nil {
+		return 1, err
+	}
+
+	return exitStatus(cmd.ProcessState), outputErr
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunPTY(t *testing.T) {
+	if _, _, err := openPTY(); err != nil {
+		t.Skipf("no pty: %v", err)
+	}
+
+	var tests = []struct {
+		command string
+		input   string
+		output  string
+		status  int
+	}{
+		{"test -t 0 && test -t 1 && tty", "", "/dev/pts/", 0},
+		{"cat", "hello\nworld", "hello\r\nworld", 0},
+		{"read line; echo \"<$line>\"; exit 3", "input\n", "<input>\r\n", 3},
+		{"kill -KILL $$", "", "", 128 + 9},
+	}
+
+	for _, test := range tests {
+		var output bytes.Buffer
+		status, err := runPTY("/bin/sh", []string{"sh", "-c", test.command}, os.Environ(), strings.NewReader(test.input), &output)
+		if err != nil {
+			t.Fatalf("runPTY(%q) = %v", test.command, err)
+		}
+		if status != test.status || !strings.HasPrefix(output.String(), test.output) {
+			t.Fatalf("runPTY(%q) = %d, %q, want %d, %q", test.command, status, output.String(), test.status, test.output)
+		}
+	}
+}
+
+func TestRunPTYNotFound(t *testing.T) {
+	if _, _, err := openPTY(); err != nil {
+		t.Skipf("no pty: %v", err)
+	}
+
+	if status, err := runPTY("/nonexistent", []string{"x"}, nil, strings.NewReader(""), new(bytes.Buffer)); err == nil || status == 0 {
+		t.Fatalf("runPTY(/nonexistent) = %d, %v", status, err)
+	}
+}
@@ -0,0 +1,107 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: selinux.go
+ * @Package: main
+ * @Version: 1.0.0
+ * @Date: 2026/10/19 20:15
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// The SELinux attributes of the calling thread. The exec context is per
+// thread, /proc/self/attr would be the one of the main thread, which Go
+// code may not be running on.
+var selinuxAttrDir = "/proc/thread-self/attr"
+
+// The attributes of a thread of su, where /proc/thread-self is missing, as
+// it is before Linux 3.17.
+var selinuxTaskAttrDir = "/proc/self/task/%d/attr"
+
+// The mount point of selinuxfs, which holds the enforce file once mounted.
+var selinuxMount = "/sys/fs/selinux"
+
+var (
+	errNoSELinux      = errors.New("SELinux is not enabled")
+	errInvalidContext = errors.New("invalid SELinux context")
+)
+
+// attrPath returns the path of the SELinux attribute name of the calling
+// thread.
+func attrPath(name string) string {
+	if _, err := os.Stat(selinuxAttrDir); err == nil {
+		return filepath.Join(selinuxAttrDir, name)
+	}
+
+	return filepath.Join(fmt.Sprintf(selinuxTaskAttrDir, syscall.Gettid()), name)
+}
+
+func selinuxEnabled() bool {
+	_, err := os.Stat(filepath.Join(selinuxMount, "enforce"))
+	return err == nil
+}
+
+// validateContext checks that context has the user:role:type:level form.
+// The level may hold ':' itself, as in u:r:untrusted_app:s0:c512,c768.
+func validateContext(context string) error {
+	var fields = strings.SplitN(context, ":", 4)
+	if len(fields) < 4 || strings.ContainsAny(context, " \t\n\x00") {
+		return fmt.Errorf("%w: %q", errInvalidContext, context)
+	}
+
+	for _, field := range fields {
+		if field == "" {
+			return fmt.Errorf("%w: %q", errInvalidContext, context)
+		}
+	}
+
+	return nil
+}
+
+// currentContext returns the context of the calling thread, as getcon does.
+func currentContext() (string, error) {
+	if !selinuxEnabled() {
+		return "", errNoSELinux
+	}
+
+	data, err := os.ReadFile(attrPath("current"))
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimRight(data, "\x00\n")), nil
+}
+
+// setExecContext sets the context the next exec of the calling thread
+// switches to, as setexeccon does.
+func setExecContext(context string) (err error) {
+	if err = validateContext(context); err != nil {
+		return
+	}
+
+	if !selinuxEnabled() {
+		return errNoSELinux
+	}
+
+	file, err := os.OpenFile(attrPath("exec"), os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	// libselinux writes the terminating NUL as well.
+	_, err = file.Write(append([]byte(context), 0))
+
+	return
+}
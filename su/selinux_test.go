@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func withSELinux(t *testing.T, enabled bool) (attr string) {
+	var dir = t.TempDir()
+	attr = filepath.Join(dir, "attr")
+	if err := os.Mkdir(attr, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if enabled {
+		if err := os.Mkdir(filepath.Join(dir, "selinux"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "selinux", "enforce"), []byte("1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, data := range map[string]string{"current": "u:r:shell:s0\x00", "exec": ""} {
+		if err := os.WriteFile(filepath.Join(attr, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var oldAttr, oldMount = selinuxAttrDir, selinuxMount
+	selinuxAttrDir, selinuxMount = attr, filepath.Join(dir, "selinux")
+	t.Cleanup(func() { selinuxAttrDir, selinuxMount = oldAttr, oldMount })
+
+	return
+}
+
+func TestValidateContext(t *testing.T) {
+	for _, context := range []string{"u:r:su:s0", "u:r:untrusted_app:s0:c512,c768"} {
+		if err := validateContext(context); err != nil {
+			t.Fatalf("validateContext(%q) = %v", context, err)
+		}
+	}
+
+	for _, context := range []string{"", "su", "u:r:su", "u::su:s0", "u:r:su:", "u:r:su:s0\n", "u:r:s u:s0"} {
+		if err := validateContext(context); !errors.Is(err, errInvalidContext) {
+			t.Fatalf("validateContext(%q) = %v", context, err)
+		}
+	}
+}
+
+func TestSetExecContext(t *testing.T) {
+	var attr = withSELinux(t, true)
+
+	if current, err := currentContext(); err != nil || current != "u:r:shell:s0" {
+		t.Fatalf("currentContext() = %q, %v", current, err)
+	}
+
+	if err := setExecContext("u:r:su:s0"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(attr, "exec")); err != nil || string(data) != "u:r:su:s0\x00" {
+		t.Fatalf("exec = %q, %v", data, err)
+	}
+
+	if err := setExecContext("su"); !errors.Is(err, errInvalidContext) {
+		t.Fatalf("setExecContext(su) = %v", err)
+	}
+}
+
+func TestNoSELinux(t *testing.T) {
+	var attr = withSELinux(t, false)
+
+	if _, err := currentContext(); !errors.Is(err, errNoSELinux) {
+		t.Fatalf("currentContext() = %v", err)
+	}
+
+	if err := setExecContext("u:r:su:s0"); !errors.Is(err, errNoSELinux) {
+		t.Fatalf("setExecContext() = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(attr, "exec")); len(data) != 0 {
+		t.Fatalf("exec = %q", data)
+	}
+}
+
+func TestAttrPathFallback(t *testing.T) {
+	var attr = withSELinux(t, true)
+
+	// Without /proc/thread-self, the task directory of the thread is used.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var task = filepath.Join(filepath.Dir(attr), "task")
+	if err := os.MkdirAll(filepath.Join(task, fmt.Sprint(syscall.Gettid())), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(attr, filepath.Join(task, fmt.Sprint(syscall.Gettid()), "attr")); err != nil {
+		t.Fatal(err)
+	}
+
+	var old = selinuxTaskAttrDir
+	selinuxTaskAttrDir = filepath.Join(task, "%d", "attr")
+	t.Cleanup(func() { selinuxTaskAttrDir = old })
+
+	if current, err := currentContext(); err != nil || current != "u:r:shell:s0" {
+		t.Fatalf("currentContext() = %q, %v", current, err)
+	}
+	if err := setExecContext("u:r:su:s0"); err != nil {
+		t.Fatal(err)
+	}
+}
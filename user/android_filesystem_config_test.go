@@ -1,7 +1,57 @@
 package user
 
-import "testing"
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+)
 
 func TestAndroidIDCount(t *testing.T) {
 	t.Log(AndroidIDCount())
 }
+
+// AndroidIDs must hold every account defined by the header it's generated
+// from, run go generate after updating the header.
+func TestAndroidIDsMatchHeader(t *testing.T) {
+	file, err := os.Open("testdata/android_filesystem_config.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var (
+		define = regexp.MustCompile(`^#define\s+AID_(\w+)\s+(\d+)`)
+		skip   = regexp.MustCompile(`^(UNUSED[0-9]+|APP|USER|USER_OFFSET|OVERFLOWUID|.*_START|.*_END)$`)
+		count  int
+	)
+
+	var scanner = bufio.NewScanner(file)
+	for scanner.Scan() {
+		match := define.FindStringSubmatch(scanner.Text())
+		if match == nil || skip.MatchString(match[1]) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(match[2], 10, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info := findAndroidIDInfoByID(uint32(id)); info == nil {
+			t.Fatalf("AID_%s (%d) is missing from AndroidIDs", match[1], id)
+		}
+		count++
+	}
+
+	if count != AndroidIDCount() {
+		t.Fatalf("header defines %d ids, AndroidIDs has %d", count, AndroidIDCount())
+	}
+
+	for _, name := range []string{"webview_zygote", "uhid", "network_stack", "secure_element"} {
+		if pw := Getpwnam(name); pw == nil {
+			t.Fatalf("Getpwnam(%q) = nil", name)
+		}
+	}
+}
@@ -1,12 +1,4 @@
-/**
- * @Author: zzy
- * @Email: zhangzhongyuan@didiglobal.com
- * @Description:
- * @File: android_ids.go
- * @Package: user
- * @Version: 1.0.0
- * @Date: 2022/10/17 16:45
- */
+// Code generated by gen_android_ids.go from testdata/android_filesystem_config.h. DO NOT EDIT.
 
 package user
 
@@ -18,8 +10,10 @@ type AndroidIDInfo struct {
 var AndroidIDs = []AndroidIDInfo{
 	{"root", AidRoot},
 
-	{"system", AidSystem},
+	{"daemon", AidDaemon},
+	{"bin", AIdBin},
 
+	{"system", AidSystem},
 	{"radio", AidRadio},
 	{"bluetooth", AidBluetooth},
 	{"graphics", AidGraphics},
@@ -41,10 +35,8 @@ var AndroidIDs = []AndroidIDInfo{
 	{"drm", AidDrm},
 	{"mdnsr", AidMDnsR},
 	{"gps", AidGPS},
-	// AidUnused1
 	{"media_rw", AidMediaRW},
 	{"mtp", AidMTP},
-	// AidUnused2
 	{"drmrpc", AidDrmRpc},
 	{"nfc", AidNfc},
 	{"sdcard_r", AidSdcardR},
@@ -57,7 +49,6 @@ var AndroidIDs = []AndroidIDInfo{
 	{"sdcard_all", AidSdcardAll},
 	{"logd", AidLogd},
 	{"shared_relro", AidSharedRELRO},
-
 	{"dbus", AidDbus},
 	{"tlsdate", AidTlsdate},
 	{"mediaex", AidMediaEx},
@@ -73,6 +64,45 @@ var AndroidIDs = []AndroidIDInfo{
 	{"nvram", AidNVRAM},
 	{"dns", AidDns},
 	{"dns_tether", AidDnsTether},
+	{"webview_zygote", AidWebviewZygote},
+	{"vehicle_network", AidVehicleNetwork},
+	{"media_audio", AidMediaAudio},
+	{"media_video", AidMediaVideo},
+	{"media_image", AidMediaImage},
+	{"tombstoned", AidTombstoned},
+	{"media_obb", AidMediaOBB},
+	{"ese", AidESE},
+	{"ota_update", AidOTAUpdate},
+	{"automotive_evs", AidAutomotiveEvs},
+	{"lowpan", AidLoWPAN},
+	{"hsm", AidHsm},
+	{"reserved_disk", AidReservedDisk},
+	{"statsd", AidStatsd},
+	{"incidentd", AidIncidentd},
+	{"secure_element", AidSecureElement},
+	{"lmkd", AidLMKD},
+	{"llkd", AidLLKD},
+	{"iorapd", AidIORAPD},
+	{"gpu_service", AidGPUService},
+	{"network_stack", AidNetworkStack},
+	{"gsid", AidGSID},
+	{"fsverity_cert", AidFSVerityCert},
+	{"credstore", AidCredStore},
+	{"external_storage", AidExternalStorage},
+	{"ext_data_rw", AidExtDataRW},
+	{"ext_obb_rw", AidExtObbRW},
+	{"context_hub", AidContextHub},
+	{"virtualizationservice", AidVirtualizationService},
+	{"artd", AidARTD},
+	{"uwb", AidUWB},
+	{"thread_network", AidThreadNetwork},
+	{"diced", AidDiced},
+	{"dmesgd", AidDmesgd},
+	{"jc_weaver", AidJcWeaver},
+	{"jc_strongbox", AidJcStrongbox},
+	{"jc_identitycred", AidJcIdentityCred},
+	{"sdk_sandbox", AidSDKSandbox},
+	{"security_log_writer", AidSecurityLogWriter},
 
 	{"shell", AidShell},
 	{"cache", AidCache},
@@ -88,6 +118,8 @@ var AndroidIDs = []AndroidIDInfo{
 	{"net_bt_stack", AidNetBtStack},
 	{"readproc", AidReadProc},
 	{"wakelock", AidWakelock},
+	{"uhid", AidUhid},
+	{"readtracefs", AidReadTracefs},
 
 	{"everybody", AidEverybody},
 	{"misc", AidMisc},
@@ -0,0 +1,158 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: android_users.go
+ * @Package: user
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 22:40
+ */
+
+package user
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// The users of the device are kept by the UserManagerService below
+// /data/system/users: userlist.xml lists their ids, and <id>.xml holds
+// the details of each.
+const usersDir = "/data/system/users"
+
+// Flags of an Android user, see android.content.pm.UserInfo.
+const (
+	UserFlagPrimary        = 0x00000001
+	UserFlagAdmin          = 0x00000002
+	UserFlagGuest          = 0x00000004
+	UserFlagRestricted     = 0x00000008
+	UserFlagInitialized    = 0x00000010
+	UserFlagManagedProfile = 0x00000020
+	UserFlagDisabled       = 0x00000040
+	UserFlagQuietMode      = 0x00000080
+	UserFlagEphemeral      = 0x00000100
+	UserFlagDemo           = 0x00000200
+	UserFlagFull           = 0x00000400
+	UserFlagSystem         = 0x00000800
+	UserFlagProfile        = 0x00001000
+)
+
+// NoProfileGroupID is the profile group of users which aren't in one.
+const NoProfileGroupID = -10000
+
+// StrictUsers makes Getpwnam and Getpwuid reject the uids of Android users
+// which don't exist on the device. The first user always exists.
+var StrictUsers bool
+
+// Since Android 12 the files may be written in the binary ABX format.
+var ErrBinaryXML = errors.New("binary XML (ABX) is not supported")
+
+var abxMagic = []byte("ABX\x00")
+
+// AndroidUser is an Android user or profile of the device.
+type AndroidUser struct {
+	ID             uint32    `json:"id" yaml:"id"`                             // user id
+	Name           string    `json:"name" yaml:"name"`                         // user name
+	Flags          uint32    `json:"flags" yaml:"flags"`                       // UserFlag bits
+	Type           string    `json:"type,omitempty" yaml:"type"`               // user type, since Android 11
+	ProfileGroupID int       `json:"profile_group_id" yaml:"profile_group_id"` // parent user of a profile, or NoProfileGroupID
+	Created        time.Time `json:"created" yaml:"created"`                   // creation time
+	LastLoggedIn   time.Time `json:"last_logged_in" yaml:"last_logged_in"`     // last time the user was in the foreground
+}
+
+type userListXML struct {
+	Users []struct {
+		ID uint32 `xml:"id,attr"`
+	} `xml:"user"`
+}
+
+type userXML struct {
+	ID             uint32 `xml:"id,attr"`
+	Flags          uint32 `xml:"flags,attr"`
+	Type           string `xml:"type,attr"`
+	Created        int64  `xml:"created,attr"`
+	LastLoggedIn   int64  `xml:"lastLoggedIn,attr"`
+	ProfileGroupID *int   `xml:"profileGroupId,attr"`
+	Name           string `xml:"name"`
+}
+
+func unmarshalUserXML(file string, v interface{}) (err error) {
+	data, err := os.ReadFile(rootPath(path.Join(usersDir, file)))
+	if err != nil {
+		return
+	}
+
+	if bytes.HasPrefix(data, abxMagic) {
+		return fmt.Errorf("%s: %w", file, ErrBinaryXML)
+	}
+
+	if err = xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	return
+}
+
+func millisToTime(millis int64) time.Time {
+	if millis == 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(millis)
+}
+
+// AndroidUsers returns the users of the device below RootDir, in the order
+// of userlist.xml. A user whose <id>.xml is missing only has its ID set.
+func AndroidUsers() (users []AndroidUser, err error) {
+	var list userListXML
+	if err = unmarshalUserXML("userlist.xml", &list); err != nil {
+		return
+	}
+
+	for _, entry := range list.Users {
+		var user = AndroidUser{ID: entry.ID, ProfileGroupID: NoProfileGroupID}
+
+		var info userXML
+		if err = unmarshalUserXML(fmt.Sprintf("%d.xml", entry.ID), &info); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			users, err = append(users, user), nil
+			continue
+		}
+
+		user.Name = info.Name
+		user.Flags = info.Flags
+		user.Type = info.Type
+		user.Created = millisToTime(info.Created)
+		user.LastLoggedIn = millisToTime(info.LastLoggedIn)
+		if info.ProfileGroupID != nil {
+			user.ProfileGroupID = *info.ProfileGroupID
+		}
+
+		users = append(users, user)
+	}
+
+	return
+}
+
+// androidUserExists reports whether the Android user id exists on the device.
+func androidUserExists(id uint32) bool {
+	if id == 0 {
+		return true
+	}
+
+	users, _ := AndroidUsers()
+	for _, user := range users {
+		if user.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package user
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAndroidUsers(t *testing.T) {
+	withRootDir(t, "testdata/root")
+
+	users, err := AndroidUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("AndroidUsers() = %+v", users)
+	}
+
+	var owner = users[0]
+	if owner.ID != 0 || owner.Name != "Owner" || owner.Flags&UserFlagPrimary == 0 || owner.ProfileGroupID != NoProfileGroupID || !owner.Created.IsZero() {
+		t.Fatalf("owner = %+v", owner)
+	}
+
+	var work = users[1]
+	if work.ID != 10 || work.Name != "Work profile" || work.Flags&UserFlagManagedProfile == 0 || work.ProfileGroupID != 0 ||
+		work.Type != "android.os.usertype.profile.MANAGED" || work.Created.UnixMilli() != 1666001234567 {
+		t.Fatalf("work profile = %+v", work)
+	}
+}
+
+func TestAndroidUsersBinaryXML(t *testing.T) {
+	var dir = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, usersDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, usersDir, "userlist.xml"), []byte("ABX\x00\x10"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	withRootDir(t, dir)
+
+	if _, err := AndroidUsers(); !errors.Is(err, ErrBinaryXML) {
+		t.Fatalf("AndroidUsers() = %v", err)
+	}
+}
+
+func TestStrictUsers(t *testing.T) {
+	withRootDir(t, "testdata/root")
+	withProfile(t, Profile{})
+
+	StrictUsers = true
+	defer func() { StrictUsers = false }()
+
+	for _, name := range []string{"u0_a48", "u10_a48", "u10_i1"} {
+		if pw := Getpwnam(name); pw == nil {
+			t.Fatalf("Getpwnam(%q) = nil", name)
+		}
+	}
+	for _, uid := range []uint32{10048, 1010048} {
+		if pw := Getpwuid(uid); pw == nil {
+			t.Fatalf("Getpwuid(%d) = nil", uid)
+		}
+	}
+
+	if pw := Getpwnam("u11_a48"); pw != nil {
+		t.Fatalf("Getpwnam(u11_a48) = %+v", *pw)
+	}
+	if pw := Getpwuid(1110048); pw != nil {
+		t.Fatalf("Getpwuid(1110048) = %+v", *pw)
+	}
+
+	StrictUsers = false
+	if pw := Getpwuid(1110048); pw == nil {
+		t.Fatal("Getpwuid(1110048) = nil without StrictUsers")
+	}
+}
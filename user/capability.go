@@ -0,0 +1,188 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: capability.go
+ * @Package: user
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 14:26
+ */
+
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Linux capabilities, see include/uapi/linux/capability.h.
+const (
+	CapChown             = 0  // override file ownership restrictions
+	CapDacOverride       = 1  // bypass file read, write and execute permission checks
+	CapDacReadSearch     = 2  // bypass file read and directory read/execute checks
+	CapFowner            = 3  // bypass checks requiring the file owner
+	CapFsetid            = 4  // keep set-user-ID and set-group-ID bits on modification
+	CapKill              = 5  // bypass permission checks for sending signals
+	CapSetGID            = 6  // manipulate process GIDs and supplementary groups
+	CapSetUID            = 7  // manipulate process UIDs
+	CapSetPCap           = 8  // modify the capability bounding set
+	CapLinuxImmutable    = 9  // set the immutable and append-only flags
+	CapNetBindService    = 10 // bind to ports below 1024
+	CapNetBroadcast      = 11 // broadcast and listen to multicast
+	CapNetAdmin          = 12 // configure interfaces, routing and firewall
+	CapNetRaw            = 13 // use RAW and PACKET sockets
+	CapIpcLock           = 14 // lock memory
+	CapIpcOwner          = 15 // bypass permission checks on System V IPC objects
+	CapSysModule         = 16 // load and unload kernel modules
+	CapSysRawIO          = 17 // perform I/O port operations
+	CapSysChroot         = 18 // use chroot
+	CapSysPtrace         = 19 // trace arbitrary processes
+	CapSysPacct          = 20 // use acct
+	CapSysAdmin          = 21 // perform a range of system administration operations
+	CapSysBoot           = 22 // use reboot and kexec_load
+	CapSysNice           = 23 // raise nice value and set scheduling policies
+	CapSysResource       = 24 // override resource limits
+	CapSysTime           = 25 // set the system clock
+	CapSysTTYConfig      = 26 // use vhangup and privileged terminal ioctls
+	CapMknod             = 27 // create special files
+	CapLease             = 28 // establish leases on arbitrary files
+	CapAuditWrite        = 29 // write records to the kernel auditing log
+	CapAuditControl      = 30 // configure the kernel auditing
+	CapSetFCap           = 31 // set file capabilities
+	CapMacOverride       = 32 // override Mandatory Access Control
+	CapMacAdmin          = 33 // configure Mandatory Access Control
+	CapSyslog            = 34 // perform privileged syslog operations
+	CapWakeAlarm         = 35 // trigger something that will wake up the system
+	CapBlockSuspend      = 36 // block system suspend
+	CapAuditRead         = 37 // read the audit log via a multicast netlink socket
+	CapPerfmon           = 38 // use performance monitoring
+	CapBPF               = 39 // use privileged BPF operations
+	CapCheckpointRestore = 40 // checkpoint/restore related operations
+
+	CapLastCap = CapCheckpointRestore
+)
+
+var capabilityNames = [CapLastCap + 1]string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_DAC_READ_SEARCH",
+	"CAP_FOWNER",
+	"CAP_FSETID",
+	"CAP_KILL",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETPCAP",
+	"CAP_LINUX_IMMUTABLE",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_NET_BROADCAST",
+	"CAP_NET_ADMIN",
+	"CAP_NET_RAW",
+	"CAP_IPC_LOCK",
+	"CAP_IPC_OWNER",
+	"CAP_SYS_MODULE",
+	"CAP_SYS_RAWIO",
+	"CAP_SYS_CHROOT",
+	"CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT",
+	"CAP_SYS_ADMIN",
+	"CAP_SYS_BOOT",
+	"CAP_SYS_NICE",
+	"CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME",
+	"CAP_SYS_TTY_CONFIG",
+	"CAP_MKNOD",
+	"CAP_LEASE",
+	"CAP_AUDIT_WRITE",
+	"CAP_AUDIT_CONTROL",
+	"CAP_SETFCAP",
+	"CAP_MAC_OVERRIDE",
+	"CAP_MAC_ADMIN",
+	"CAP_SYSLOG",
+	"CAP_WAKE_ALARM",
+	"CAP_BLOCK_SUSPEND",
+	"CAP_AUDIT_READ",
+	"CAP_PERFMON",
+	"CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+var ErrUnknownCapability = errors.New("unknown capability")
+
+// CapMaskLong returns the capabilities mask of capability c, like the
+// CAP_MASK_LONG macro of the AOSP fs_config tables.
+func CapMaskLong(c uint) uint64 {
+	return 1 << c
+}
+
+// CapabilityName returns the name of capability c, such as "CAP_SETUID", or
+// "" if c is unknown.
+func CapabilityName(c uint) string {
+	if c > CapLastCap {
+		return ""
+	}
+
+	return capabilityNames[c]
+}
+
+// CapabilityFromName translates a capability name to its number. The name is
+// matched case-insensitively, with or without the "CAP_" prefix, so the
+// config.fs spelling "SETUID" works as well as "CAP_SETUID".
+func CapabilityFromName(name string) (c uint, ok bool) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "CAP_") {
+		name = "CAP_" + name
+	}
+
+	for i, capName := range capabilityNames {
+		if capName == name {
+			return uint(i), true
+		}
+	}
+
+	return
+}
+
+// ParseCapabilities parses a set of capabilities such as "CAP_SETUID|CAP_SETGID"
+// into a mask. Names may be separated by '|', ',' or white space, and a plain
+// number is taken as the capability number.
+func ParseCapabilities(str string) (mask uint64, err error) {
+	var fields = strings.FieldsFunc(str, func(r rune) bool {
+		return r == '|' || r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+
+	for _, field := range fields {
+		c, ok := CapabilityFromName(field)
+		if !ok {
+			num, err := strconv.ParseUint(field, 10, 8)
+			if err != nil || num > CapLastCap {
+				return 0, fmt.Errorf("%w: %q", ErrUnknownCapability, field)
+			}
+			c = uint(num)
+		}
+
+		mask |= CapMaskLong(c)
+	}
+
+	return
+}
+
+// FormatCapabilities formats a capabilities mask as names joined by '|', in
+// ascending order. Bits without a known capability are appended as a hex
+// mask. An empty mask is formatted as "".
+func FormatCapabilities(mask uint64) string {
+	var names []string
+	for c := uint(0); c <= CapLastCap; c++ {
+		if mask&CapMaskLong(c) != 0 {
+			names = append(names, capabilityNames[c])
+			mask &^= CapMaskLong(c)
+		}
+	}
+
+	if mask != 0 {
+		names = append(names, fmt.Sprintf("%#x", mask))
+	}
+
+	return strings.Join(names, "|")
+}
@@ -0,0 +1,46 @@
+package user
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	var tests = []struct {
+		str  string
+		mask uint64
+	}{
+		{"", 0},
+		{"CAP_SETUID|CAP_SETGID", CapMaskLong(CapSetUID) | CapMaskLong(CapSetGID)},
+		{"net_admin, SYS_NICE", CapMaskLong(CapNetAdmin) | CapMaskLong(CapSysNice)},
+		{"BLOCK_SUSPEND 40", CapMaskLong(CapBlockSuspend) | CapMaskLong(CapCheckpointRestore)},
+	}
+
+	for _, test := range tests {
+		mask, err := ParseCapabilities(test.str)
+		if err != nil || mask != test.mask {
+			t.Fatalf("ParseCapabilities(%q) = %#x, %v, want %#x", test.str, mask, err, test.mask)
+		}
+	}
+
+	for _, str := range []string{"CAP_FLY", "41", "CAP_SETUID|-1"} {
+		if _, err := ParseCapabilities(str); !errors.Is(err, ErrUnknownCapability) {
+			t.Fatalf("ParseCapabilities(%q) err = %v", str, err)
+		}
+	}
+}
+
+func TestFormatCapabilities(t *testing.T) {
+	if str := FormatCapabilities(CapMaskLong(CapSetUID) | CapMaskLong(CapSetGID)); str != "CAP_SETGID|CAP_SETUID" {
+		t.Fatalf("FormatCapabilities = %q", str)
+	}
+	if str := FormatCapabilities(CapMaskLong(CapBPF) | 1<<63); str != "CAP_BPF|0x8000000000000000" {
+		t.Fatalf("FormatCapabilities = %q", str)
+	}
+
+	for c := uint(0); c <= CapLastCap; c++ {
+		if mask, err := ParseCapabilities(FormatCapabilities(CapMaskLong(c))); err != nil || mask != CapMaskLong(c) {
+			t.Fatalf("capability %d does not round trip: %#x, %v", c, mask, err)
+		}
+	}
+}
@@ -11,15 +11,17 @@
 package user
 
 import (
+	"errors"
+	"os"
 	"strings"
 )
 
 type FSPathConfig struct {
-	Mode         uint
-	UID          uint
-	GID          uint
-	Capabilities uint64
-	Prefix       string
+	Mode         uint   `json:"mode" yaml:"mode"`
+	UID          uint   `json:"uid" yaml:"uid"`
+	GID          uint   `json:"gid" yaml:"gid"`
+	Capabilities uint64 `json:"capabilities" yaml:"capabilities"`
+	Prefix       string `json:"prefix" yaml:"prefix"`
 }
 
 // AndroidDirs
@@ -166,14 +168,14 @@ var AndroidFiles = []FSPathConfig{
 	{04750, AidRoot, AidShell, 0, "system/xbin/su"},
 	// the following files have enhanced capabilities and ARE included
 	// in user builds.
-	//{00700, AidSystem, AidShell, CAP_MASK_LONG(CAP_BLOCK_SUSPEND),
-	//	"system/bin/inputflinger"},
-	//{00750, AidRoot, AidShell, CAP_MASK_LONG(CAP_SETUID) |
-	//	CAP_MASK_LONG(CAP_SETGID),
-	//	"system/bin/run-as"},
-	//{00750, AidRoot, AidShell, CAP_MASK_LONG(CAP_SETUID) |
-	//	CAP_MASK_LONG(CAP_SETGID),
-	//	"system/bin/simpleperf_app_runner"},
+	{00700, AidSystem, AidShell, CapMaskLong(CapBlockSuspend),
+		"system/bin/inputflinger"},
+	{00750, AidRoot, AidShell, CapMaskLong(CapSetUID) |
+		CapMaskLong(CapSetGID),
+		"system/bin/run-as"},
+	{00750, AidRoot, AidShell, CapMaskLong(CapSetUID) |
+		CapMaskLong(CapSetGID),
+		"system/bin/simpleperf_app_runner"},
 	{00755, AidRoot, AidRoot, 0, "first_stage_ramdisk/system/bin/e2fsck"},
 
 	//	#ifdef __LP64__
@@ -216,3 +218,136 @@ func isPartition(path string) bool {
 
 	return false
 }
+
+// fsConfigMatch reports whether input matches the fs_config pattern. A trailing
+// * matches the rest of the path, any other * matches within a single path
+// segment, so "system/apex/*/bin" only matches one apex directory.
+func fsConfigMatch(pattern, input string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] != '*' {
+			if len(input) == 0 || input[0] != pattern[0] {
+				return false
+			}
+			pattern, input = pattern[1:], input[1:]
+			continue
+		}
+
+		if pattern = pattern[1:]; pattern == "" {
+			return true
+		}
+
+		for i := 0; i <= len(input); i++ {
+			if fsConfigMatch(pattern, input[i:]) {
+				return true
+			}
+			if i < len(input) && input[i] == '/' {
+				break
+			}
+		}
+
+		return false
+	}
+
+	return input == ""
+}
+
+// Logical partitions which may also be found below another partition.
+var logicalPartitions = []string{"system/product/", "system/system_ext/", "system/vendor/", "vendor/odm/"}
+
+func fsConfigCmp(dir bool, prefix, path string) bool {
+	var (
+		pattern = prefix
+		input   = path
+	)
+
+	// Massage pattern and input so that directories have to end with /.
+	if dir {
+		if !strings.HasSuffix(input, "/") {
+			input += "/"
+		}
+
+		if !strings.HasSuffix(pattern, "/*") {
+			if strings.HasSuffix(pattern, "/") {
+				pattern += "*"
+			} else {
+				pattern += "/*"
+			}
+		}
+	}
+
+	if fsConfigMatch(pattern, input) {
+		return true
+	}
+
+	// Check match between logical partition's files and patterns.
+	for _, partition := range logicalPartitions {
+		if strings.HasPrefix(input, partition) {
+			var inputInPartition = input[strings.IndexByte(input, '/')+1:]
+			if !isPartition(inputInPartition) {
+				continue
+			}
+			if fsConfigMatch(pattern, inputInPartition) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// fsConfigOpen reads the fs_config image of partition which. targetOutDir is
+// the directory holding the content of the system partition, it's tried
+// before the image below RootDir.
+func fsConfigOpen(dir bool, which int, targetOutDir string) (configs []FSPathConfig, err error) {
+	var name = conf[which][0]
+	if dir {
+		name = conf[which][1]
+	}
+
+	if targetOutDir != "" {
+		// As we cannot guarantee targetOutDir ends with '/system' or with or without a
+		// trailing slash, need to strip them carefully.
+		var out = targetOutDir
+		if len(out) >= 2 && strings.HasSuffix(out, "/") {
+			out = out[:len(out)-1]
+		}
+		out = strings.TrimSuffix(out, "/system")
+
+		if configs, err = ReadFSConfigFile(out + name); !errors.Is(err, os.ErrNotExist) {
+			return
+		}
+	}
+
+	return ReadFSConfigFile(rootPath(name))
+}
+
+// FSConfig resolves the mode, uid, gid and capabilities of path the way
+// fs_config() in libcutils does. The fs_config images of the partitions are
+// tried before AndroidDirs and AndroidFiles, and the first match wins. The
+// Prefix of the result is the pattern that matched, "" for the default.
+func FSConfig(path string, dir bool, targetOutDir string) FSPathConfig {
+	path = strings.TrimPrefix(path, "/")
+
+	for which := range conf {
+		// A damaged image still provides the records before the damaged one.
+		configs, _ := fsConfigOpen(dir, which, targetOutDir)
+		for _, config := range configs {
+			if fsConfigCmp(dir, config.Prefix, path) {
+				return config
+			}
+		}
+	}
+
+	var configs = AndroidFiles
+	if dir {
+		configs = AndroidDirs
+	}
+
+	for _, config := range configs {
+		if config.Prefix == "" || fsConfigCmp(dir, config.Prefix, path) {
+			return config
+		}
+	}
+
+	return FSPathConfig{}
+}
@@ -0,0 +1,149 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: fs_config_file.go
+ * @Package: user
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 11:03
+ */
+
+package user
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// The binary format of the fs_config_dirs and fs_config_files images, a
+// sequence of little-endian fs_path_config_from_file records:
+//
+//	struct fs_path_config_from_file {
+//	    uint16_t len;
+//	    uint16_t mode;
+//	    uint16_t uid;
+//	    uint16_t gid;
+//	    uint64_t capabilities;
+//	    char prefix[];
+//	} __attribute__((__aligned__(sizeof(uint64_t))));
+//
+// len is the size of the whole record, including the NUL terminated prefix
+// and the padding up to the next 8 byte boundary.
+const fsPathConfigHeaderSize = 16
+
+var (
+	ErrFSConfigTruncated = errors.New("fs_config record is truncated")
+	ErrFSConfigCorrupted = errors.New("fs_config record is corrupted")
+	ErrFSConfigOverflow  = errors.New("fs_config record field overflows")
+)
+
+// FSConfigError describes a damaged record in a fs_config image.
+type FSConfigError struct {
+	Offset int   // offset of the record in the image
+	Err    error // ErrFSConfigTruncated or ErrFSConfigCorrupted
+}
+
+func (e *FSConfigError) Error() string {
+	return fmt.Sprintf("%v at offset %d", e.Err, e.Offset)
+}
+
+func (e *FSConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ParseFSConfig decodes the records of a fs_config_dirs or fs_config_files
+// image. Decoding stops at the first damaged record, the records before it
+// are returned along with a *FSConfigError.
+func ParseFSConfig(data []byte) (configs []FSPathConfig, err error) {
+	for offset := 0; offset < len(data); {
+		var record = data[offset:]
+		if len(record) < fsPathConfigHeaderSize {
+			return configs, &FSConfigError{Offset: offset, Err: ErrFSConfigTruncated}
+		}
+
+		var size = int(binary.LittleEndian.Uint16(record[0:]))
+		if size <= fsPathConfigHeaderSize {
+			return configs, &FSConfigError{Offset: offset, Err: ErrFSConfigCorrupted}
+		}
+		if size > len(record) {
+			return configs, &FSConfigError{Offset: offset, Err: ErrFSConfigTruncated}
+		}
+
+		// The prefix must be terminated by a NUL inside the record.
+		var prefix = record[fsPathConfigHeaderSize:size]
+		var end = bytes.IndexByte(prefix, 0)
+		if end < 0 {
+			return configs, &FSConfigError{Offset: offset, Err: ErrFSConfigCorrupted}
+		}
+
+		configs = append(configs, FSPathConfig{
+			Mode:         uint(binary.LittleEndian.Uint16(record[2:])),
+			UID:          uint(binary.LittleEndian.Uint16(record[4:])),
+			GID:          uint(binary.LittleEndian.Uint16(record[6:])),
+			Capabilities: binary.LittleEndian.Uint64(record[8:]),
+			Prefix:       string(prefix[:end]),
+		})
+
+		offset += size
+	}
+
+	return
+}
+
+// MarshalFSConfig encodes configs into a fs_config_dirs or fs_config_files
+// image, byte for byte the way build/tools/fs_config/fs_config_generate.c
+// does. The records are written in order, so the most specific prefixes
+// should come first.
+func MarshalFSConfig(configs []FSPathConfig) (data []byte, err error) {
+	for _, config := range configs {
+		if config.Mode > math.MaxUint16 || config.UID > math.MaxUint16 || config.GID > math.MaxUint16 {
+			return nil, fmt.Errorf("%w: %q", ErrFSConfigOverflow, config.Prefix)
+		}
+		if strings.IndexByte(config.Prefix, 0) >= 0 {
+			return nil, fmt.Errorf("%w: %q", ErrFSConfigCorrupted, config.Prefix)
+		}
+
+		// Aligned to the 8 byte boundary of the capabilities, zero padded.
+		var size = (fsPathConfigHeaderSize + len(config.Prefix) + 1 + 7) &^ 7
+		if size > math.MaxUint16 {
+			return nil, fmt.Errorf("%w: %q", ErrFSConfigOverflow, config.Prefix)
+		}
+
+		var record = make([]byte, size)
+		binary.LittleEndian.PutUint16(record[0:], uint16(size))
+		binary.LittleEndian.PutUint16(record[2:], uint16(config.Mode))
+		binary.LittleEndian.PutUint16(record[4:], uint16(config.UID))
+		binary.LittleEndian.PutUint16(record[6:], uint16(config.GID))
+		binary.LittleEndian.PutUint64(record[8:], config.Capabilities)
+		copy(record[fsPathConfigHeaderSize:], config.Prefix)
+
+		data = append(data, record...)
+	}
+
+	return
+}
+
+// WriteFSConfigFile encodes configs and writes them to the image at path.
+func WriteFSConfigFile(path string, configs []FSPathConfig) (err error) {
+	data, err := MarshalFSConfig(configs)
+	if err != nil {
+		return
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadFSConfigFile reads and decodes a fs_config_dirs or fs_config_files image.
+func ReadFSConfigFile(path string) (configs []FSPathConfig, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	return ParseFSConfig(data)
+}
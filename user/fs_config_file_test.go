@@ -0,0 +1,146 @@
+package user
+
+import (
+	"errors"
+	"testing"
+)
+
+// Records of a system/etc/fs_config_files as build/tools/fs_config writes
+// it, byte for byte: len, mode, uid, gid and capabilities, little-endian,
+// then the prefix, NUL terminated and padded to 8 bytes.
+var (
+	runAsRecord = []byte{
+		0x28, 0x00, 0xe8, 0x01, 0x00, 0x00, 0xd0, 0x07, // 40, 0750, root, shell
+		0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // CAP_SETGID|CAP_SETUID
+		's', 'y', 's', 't', 'e', 'm', '/', 'b',
+		'i', 'n', '/', 'r', 'u', 'n', '-', 'a',
+		's', 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	suRecord = []byte{
+		0x20, 0x00, 0xe8, 0x09, 0x00, 0x00, 0xd0, 0x07, // 32, 04750, root, shell
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		's', 'y', 's', 't', 'e', 'm', '/', 'x',
+		'b', 'i', 'n', '/', 's', 'u', 0x00, 0x00,
+	}
+	vendorBinRecord = []byte{
+		0x20, 0x00, 0xed, 0x01, 0x00, 0x00, 0xd0, 0x07, // 32, 0755, root, shell
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		'v', 'e', 'n', 'd', 'o', 'r', '/', 'b',
+		'i', 'n', '/', '*', 0x00, 0x00, 0x00, 0x00,
+	}
+	defaultRecord = []byte{
+		0x18, 0x00, 0xa4, 0x01, 0x00, 0x00, 0x00, 0x00, // 24, 0644, root, root
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+)
+
+// concat joins the records into an image.
+func concat(records ...[]byte) (data []byte) {
+	for _, record := range records {
+		data = append(data, record...)
+	}
+
+	return
+}
+
+func TestParseFSConfig(t *testing.T) {
+	configs, err := ParseFSConfig(concat(suRecord, runAsRecord))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want = []FSPathConfig{
+		{04750, AidRoot, AidShell, 0, "system/xbin/su"},
+		{00750, AidRoot, AidShell, 0xc0, "system/bin/run-as"},
+	}
+	if len(configs) != len(want) {
+		t.Fatalf("got %d records, want %d", len(configs), len(want))
+	}
+	for i := range want {
+		if configs[i] != want[i] {
+			t.Fatalf("record %d = %+v, want %+v", i, configs[i], want[i])
+		}
+	}
+}
+
+func TestParseFSConfigDamaged(t *testing.T) {
+	var good = vendorBinRecord
+
+	var noNul = concat(vendorBinRecord)
+	for i := 16; i < len(noNul); i++ {
+		noNul[i] = 'x'
+	}
+
+	var shortLen = concat(vendorBinRecord)
+	shortLen[0] = 0x10
+
+	var tests = []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"short header", concat(good, []byte{0x18, 0x00, 0xed}), ErrFSConfigTruncated},
+		{"short prefix", concat(good, good[:len(good)-8]), ErrFSConfigTruncated},
+		{"missing nul", concat(good, noNul), ErrFSConfigCorrupted},
+		{"bad length", concat(good, shortLen), ErrFSConfigCorrupted},
+	}
+
+	for _, test := range tests {
+		configs, err := ParseFSConfig(test.data)
+		if !errors.Is(err, test.err) {
+			t.Fatalf("%s: err = %v, want %v", test.name, err, test.err)
+		}
+
+		var fsErr *FSConfigError
+		if !errors.As(err, &fsErr) || fsErr.Offset != len(good) {
+			t.Fatalf("%s: err = %#v, want offset %d", test.name, err, len(good))
+		}
+
+		if len(configs) != 1 || configs[0].Prefix != "vendor/bin/*" {
+			t.Fatalf("%s: configs = %+v", test.name, configs)
+		}
+	}
+}
+
+func TestMarshalFSConfig(t *testing.T) {
+	var configs = []FSPathConfig{
+		{04750, AidRoot, AidShell, 0, "system/xbin/su"},
+		{00755, AidRoot, AidShell, 0, "vendor/bin/*"},
+		{00644, AidRoot, AidRoot, 0, ""},
+	}
+
+	data, err := MarshalFSConfig(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want = concat(suRecord, vendorBinRecord, defaultRecord)
+	if string(data) != string(want) {
+		t.Fatalf("data = %x, want %x", data, want)
+	}
+
+	// Every built-in table survives the round trip through the reader.
+	for _, table := range [][]FSPathConfig{AndroidDirs, AndroidFiles} {
+		if data, err = MarshalFSConfig(table); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ParseFSConfig(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(table) {
+			t.Fatalf("got %d records, want %d", len(got), len(table))
+		}
+		for i := range table {
+			if got[i] != table[i] {
+				t.Fatalf("record %d = %+v, want %+v", i, got[i], table[i])
+			}
+		}
+	}
+
+	if _, err = MarshalFSConfig([]FSPathConfig{{00755, AidUserOffset, AidRoot, 0, "bin/*"}}); !errors.Is(err, ErrFSConfigOverflow) {
+		t.Fatalf("err = %v, want %v", err, ErrFSConfigOverflow)
+	}
+}
@@ -0,0 +1,67 @@
+package user
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSConfig(t *testing.T) {
+	withRootDir(t, "testdata/root")
+
+	var tests = []struct {
+		path   string
+		dir    bool
+		mode   uint
+		uid    uint
+		gid    uint
+		prefix string
+	}{
+		{"/system/xbin/su", false, 04750, AidRoot, AidShell, "system/xbin/su"},
+		{"system/bin/ls", false, 00755, AidRoot, AidShell, "system/bin/*"},
+		{"system/etc/rc.local", false, 00555, AidRoot, AidRoot, "system/etc/rc.*"},
+		{"system/etc/hosts", false, 00644, AidRoot, AidRoot, ""},
+		{"data/local/tmp", true, 00771, AidShell, AidShell, "data/local/tmp"},
+		{"data/local/tmp/x", true, 00771, AidShell, AidShell, "data/local/tmp"},
+		{"system/apex/com.android.art/bin", true, 00751, AidRoot, AidShell, "system/apex/*/bin"},
+		{"system/apex/com.android.art/x/bin", true, 00755, AidRoot, AidRoot, ""},
+		{"system/apex/com.android.art/bin/dex2oat", false, 00755, AidRoot, AidShell, "system/apex/*/bin/*"},
+		{"vendor/odm/bin/hdmid", false, 00755, AidRoot, AidShell, "odm/bin/*"},
+		{"system/product/bin", true, 00751, AidRoot, AidShell, "product/bin"},
+	}
+
+	for _, test := range tests {
+		config := FSConfig(test.path, test.dir, "")
+		if config.Mode != test.mode || config.UID != test.uid || config.GID != test.gid || config.Prefix != test.prefix {
+			t.Fatalf("FSConfig(%q, %v) = %+v", test.path, test.dir, config)
+		}
+	}
+}
+
+func TestFSConfigPartitionFile(t *testing.T) {
+	withRootDir(t, "testdata/root")
+
+	var out = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(out, "vendor/etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var files = []FSPathConfig{
+		{00700, AidSystem, AidSystem, 1 << 12, "vendor/bin/hooks/*"},
+		{06755, AidRoot, AidRoot, 0, "system/xbin/su"},
+	}
+	if err := WriteFSConfigFile(filepath.Join(out, "vendor/etc/fs_config_files"), files); err != nil {
+		t.Fatal(err)
+	}
+
+	// The partition images take precedence over the built-in table.
+	for i, path := range []string{"vendor/bin/hooks/netd", "system/xbin/su"} {
+		if config := FSConfig(path, false, filepath.Join(out, "system")+"/"); config != files[i] {
+			t.Fatalf("FSConfig(%q) = %+v, want %+v", path, config, files[i])
+		}
+	}
+
+	if config := FSConfig("vendor/bin/sh", false, out); config.Prefix != "vendor/bin/*" {
+		t.Fatalf("FSConfig(vendor/bin/sh) = %+v", config)
+	}
+}
@@ -0,0 +1,204 @@
+//go:build ignore
+
+// This program generates android_filesystem_config.go and android_ids.go
+// from an AOSP android_filesystem_config.h, the same way fs_config_generator.py
+// generates generated_android_ids.h. It is invoked by go generate.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Go identifiers which don't follow the AID_FOO_BAR -> AidFooBar rule. They
+// predate the generator and are kept for compatibility.
+var goNames = map[string]string{
+	"AID_BIN":                   "AIdBin",
+	"AID_SDCARD_RW":             "AidSdcardRW",
+	"AID_MDNSR":                 "AidMDnsR",
+	"AID_GPS":                   "AidGPS",
+	"AID_MEDIA_RW":              "AidMediaRW",
+	"AID_MTP":                   "AidMTP",
+	"AID_DRMRPC":                "AidDrmRpc",
+	"AID_SDCARD_AV":             "AidSdcardAV",
+	"AID_SHARED_RELRO":          "AidSharedRELRO",
+	"AID_AUDIOSERVER":           "AidAudioServer",
+	"AID_METRICSD":              "AidMetricSD",
+	"AID_WEBSERV":               "AidWebServ",
+	"AID_DEBUGGERD":             "AidDebugGerd",
+	"AID_CAMERASERVER":          "AidCameraServer",
+	"AID_NVRAM":                 "AidNVRAM",
+	"AID_MEDIA_OBB":             "AidMediaOBB",
+	"AID_ESE":                   "AidESE",
+	"AID_OTA_UPDATE":            "AidOTAUpdate",
+	"AID_LOWPAN":                "AidLoWPAN",
+	"AID_LMKD":                  "AidLMKD",
+	"AID_LLKD":                  "AidLLKD",
+	"AID_IORAPD":                "AidIORAPD",
+	"AID_GPU_SERVICE":           "AidGPUService",
+	"AID_GSID":                  "AidGSID",
+	"AID_FSVERITY_CERT":         "AidFSVerityCert",
+	"AID_CREDSTORE":             "AidCredStore",
+	"AID_EXT_DATA_RW":           "AidExtDataRW",
+	"AID_EXT_OBB_RW":            "AidExtObbRW",
+	"AID_VIRTUALIZATIONSERVICE": "AidVirtualizationService",
+	"AID_ARTD":                  "AidARTD",
+	"AID_UWB":                   "AidUWB",
+	"AID_JC_IDENTITYCRED":       "AidJcIdentityCred",
+	"AID_SDK_SANDBOX":           "AidSDKSandbox",
+	"AID_READPROC":              "AidReadProc",
+	"AID_READTRACEFS":           "AidReadTracefs",
+	"AID_OVERFLOWUID":           "AidOverFlowUid",
+}
+
+// Some of the AIDs like AID_MEDIA_EX had names like mediaex, list a map of
+// things to fixup.
+var friendlyFixups = map[string]string{
+	"media_drm":   "mediadrm",
+	"media_ex":    "mediaex",
+	"media_codec": "mediacodec",
+}
+
+var (
+	aidDefine = regexp.MustCompile(`^#define\s+(AID_\w+)\s+(\w+)\s*(?:/\*\s*(.*?)\s*\*/|//\s*(.*))?$`)
+	aidToken  = regexp.MustCompile(`\bAID_[A-Z0-9_]+\b`)
+	// Ranges, deprecated aliases and the kernel overflow uid are no accounts.
+	aidSkip = regexp.MustCompile(`^AID_(UNUSED[0-9]+|APP|USER|USER_OFFSET|OVERFLOWUID|.*_START|.*_END)$`)
+)
+
+const masterComment = "This is the master Users and Groups config for the platform."
+
+func goName(aid string) string {
+	if name, ok := goNames[aid]; ok {
+		return name
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Aid")
+	for _, word := range strings.Split(strings.TrimPrefix(aid, "AID_"), "_") {
+		if word != "" {
+			sb.WriteString(word[:1] + strings.ToLower(word[1:]))
+		}
+	}
+
+	return sb.String()
+}
+
+func friendlyName(aid string) string {
+	var name = strings.ToLower(strings.TrimPrefix(aid, "AID_"))
+	if fixup, ok := friendlyFixups[name]; ok {
+		return fixup
+	}
+
+	return name
+}
+
+func replaceAIDs(text string) string {
+	return aidToken.ReplaceAllStringFunc(text, goName)
+}
+
+func writeSource(filename string, src []byte) {
+	src, err := format.Source(src)
+	if err != nil {
+		log.Fatalf("format %s: %v", filename, err)
+	}
+
+	if err = os.WriteFile(filename, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+	var header = "testdata/android_filesystem_config.h"
+	if len(os.Args) > 1 {
+		header = os.Args[1]
+	}
+
+	file, err := os.Open(header)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	var (
+		consts  bytes.Buffer
+		ids     bytes.Buffer
+		started bool
+		inConst bool
+		gap     bool
+	)
+
+	fmt.Fprintf(&consts, "// Code generated by gen_android_ids.go from %s. DO NOT EDIT.\n\n", header)
+	fmt.Fprintf(&consts, "package user\n\n")
+	fmt.Fprintf(&consts, "// generator script: https://android.googlesource.com/platform/build/+/refs/heads/android13-s3-release/tools/fs_config/fs_config_generator.py\n\n")
+
+	fmt.Fprintf(&ids, "// Code generated by gen_android_ids.go from %s. DO NOT EDIT.\n\n", header)
+	fmt.Fprintf(&ids, "package user\n\n")
+	fmt.Fprintf(&ids, "type AndroidIDInfo struct {\n\tName string\n\tAid  uint32\n}\n\n")
+	fmt.Fprintf(&ids, "var AndroidIDs = []AndroidIDInfo{\n")
+
+	var scanner = bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line = strings.TrimRight(scanner.Text(), " \t")
+		if !started {
+			if !strings.Contains(line, masterComment) {
+				continue
+			}
+			started = true
+		}
+
+		if match := aidDefine.FindStringSubmatch(line); match != nil {
+			if !inConst {
+				fmt.Fprintf(&consts, "const (\n")
+				inConst = true
+			}
+
+			var aid, value, comment = match[1], match[2], match[3] + match[4]
+			fmt.Fprintf(&consts, "%s = %s", goName(aid), replaceAIDs(value))
+			if comment != "" {
+				fmt.Fprintf(&consts, " // %s", replaceAIDs(comment))
+			}
+			fmt.Fprintln(&consts)
+
+			if !aidSkip.MatchString(aid) {
+				if gap {
+					fmt.Fprintln(&ids)
+				}
+				fmt.Fprintf(&ids, "{%q, %s},\n", friendlyName(aid), goName(aid))
+				gap = false
+			}
+			continue
+		}
+
+		// Other preprocessor lines have no Go equivalent.
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if inConst {
+			gap = true
+		} else if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fmt.Fprintln(&consts, line)
+	}
+	if err = scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	if !inConst {
+		log.Fatalf("%s: no AID defines found", header)
+	}
+
+	fmt.Fprintf(&consts, ")\n")
+	fmt.Fprintf(&ids, "}\n\n")
+	fmt.Fprintf(&ids, "func AndroidIDCount() int {\n\treturn len(AndroidIDs)\n}\n")
+
+	writeSource("android_filesystem_config.go", consts.Bytes())
+	writeSource("android_ids.go", ids.Bytes())
+}
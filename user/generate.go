@@ -0,0 +1,3 @@
+package user
+
+//go:generate go run gen_android_ids.go testdata/android_filesystem_config.h
@@ -0,0 +1,218 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: grp_pwd_ent.go
+ * @Package: user
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 20:40
+ */
+
+package user
+
+import (
+	"math"
+	"sync"
+)
+
+// The stages of an enumeration, in the order the entries are yielded.
+const (
+	entAndroidIDs = iota
+	entFiles
+	entOem
+	entApps
+	entDone
+)
+
+// entCursor walks the entries of the passwd or group database the way the
+// getpwent/getgrent of bionic do: the platform AIDs, then the entries of
+// the partition files, the OEM ranges and the app ids of the first user.
+// u1_a* and higher aren't reported, or the enumeration would never end.
+type entCursor[T any] struct {
+	isGroup bool
+	files   []T
+	id      func(entry *T) uint32
+	fromAID func(info AndroidIDInfo) *T
+	fromOem func(id uint32) *T
+	fromApp func(id uint32) *T
+
+	stage  int
+	index  int
+	aids   []AndroidIDInfo
+	ranges []IDRange
+	oemID  uint32
+	appID  uint32
+	seen   map[uint32]bool // ids of the file entries
+}
+
+func (c *entCursor[T]) init() {
+	var p = CurrentProfile()
+	c.aids = p.AndroidIDs()
+	c.ranges = p.oemRanges()
+	c.seen = make(map[uint32]bool)
+	for i := range c.files {
+		c.seen[c.id(&c.files[i])] = true
+	}
+}
+
+// next returns the next entry, or nil at the end of the enumeration.
+func (c *entCursor[T]) next() *T {
+	for {
+		switch c.stage {
+		case entAndroidIDs:
+			if c.index < len(c.aids) {
+				c.index++
+				return c.fromAID(c.aids[c.index-1])
+			}
+			c.stage, c.index = entFiles, 0
+
+		case entFiles:
+			if c.index < len(c.files) {
+				c.index++
+				return &c.files[c.index-1]
+			}
+			c.stage, c.index = entOem, 0
+
+		case entOem:
+			if c.index >= len(c.ranges) {
+				c.stage, c.appID = entApps, 0
+				continue
+			}
+
+			// OEM ids already listed by a partition file aren't repeated.
+			var r = c.ranges[c.index]
+			if c.oemID < r.Start {
+				c.oemID = r.Start
+			}
+			for ; c.oemID <= r.End; c.oemID++ {
+				if !c.seen[c.oemID] {
+					c.oemID++
+					return c.fromOem(c.oemID - 1)
+				}
+			}
+			c.index++
+
+		case entApps:
+			if c.appID = getNextAppID(c.appID, c.isGroup); c.appID == math.MaxUint32 {
+				c.stage = entDone
+				continue
+			}
+			if entry := c.fromApp(c.appID); entry != nil {
+				return entry
+			}
+
+		default:
+			return nil
+		}
+	}
+}
+
+func newPasswdCursor() *entCursor[Passwd] {
+	var cursor = &entCursor[Passwd]{
+		id:      func(pw *Passwd) uint32 { return pw.UID },
+		fromAID: androidIInfoToPasswd,
+		fromOem: oemPasswd,
+		fromApp: appIDToPasswd,
+	}
+
+	for _, file := range passwdFiles {
+		list, _ := readPasswdFile(file[0], file[1])
+		cursor.files = append(cursor.files, list...)
+	}
+	cursor.init()
+
+	return cursor
+}
+
+func newGroupCursor() *entCursor[Group] {
+	var cursor = &entCursor[Group]{
+		isGroup: true,
+		id:      func(group *Group) uint32 { return group.GID },
+		fromAID: androidIInfoToGroup,
+		fromOem: oemGroup,
+		fromApp: appIDToGroup,
+	}
+
+	for _, file := range groupFiles {
+		list, _ := readGroupFile(file[0], file[1])
+		cursor.files = append(cursor.files, list...)
+	}
+	cursor.init()
+
+	return cursor
+}
+
+// Passwds calls fn for every passwd entry, in the order of Getpwent, until
+// fn returns false.
+func Passwds(fn func(pw *Passwd) bool) {
+	var cursor = newPasswdCursor()
+	for pw := cursor.next(); pw != nil && fn(pw); pw = cursor.next() {
+	}
+}
+
+// Groups calls fn for every group entry, in the order of Getgrent, until
+// fn returns false.
+func Groups(fn func(group *Group) bool) {
+	var cursor = newGroupCursor()
+	for group := cursor.next(); group != nil && fn(group); group = cursor.next() {
+	}
+}
+
+var (
+	entMutex     sync.Mutex
+	passwdCursor *entCursor[Passwd]
+	groupCursor  *entCursor[Group]
+)
+
+// Setpwent rewinds the enumeration of Getpwent to the first entry.
+func Setpwent() {
+	entMutex.Lock()
+	defer entMutex.Unlock()
+
+	passwdCursor = nil
+}
+
+// Getpwent returns the next passwd entry, or nil after the last one. The
+// platform AIDs come first, then the partition file entries, the OEM ranges
+// and the app ids of the first user.
+func Getpwent() *Passwd {
+	entMutex.Lock()
+	defer entMutex.Unlock()
+
+	if passwdCursor == nil {
+		passwdCursor = newPasswdCursor()
+	}
+
+	return passwdCursor.next()
+}
+
+// Endpwent ends the enumeration of Getpwent.
+func Endpwent() {
+	Setpwent()
+}
+
+// Setgrent rewinds the enumeration of Getgrent to the first entry.
+func Setgrent() {
+	entMutex.Lock()
+	defer entMutex.Unlock()
+
+	groupCursor = nil
+}
+
+// Getgrent returns the next group entry, or nil after the last one, in the
+// order of Getpwent. The app ids include the cache, ext and shared gids.
+func Getgrent() *Group {
+	entMutex.Lock()
+	defer entMutex.Unlock()
+
+	if groupCursor == nil {
+		groupCursor = newGroupCursor()
+	}
+
+	return groupCursor.next()
+}
+
+// Endgrent ends the enumeration of Getgrent.
+func Endgrent() {
+	Setgrent()
+}
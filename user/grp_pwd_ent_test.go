@@ -0,0 +1,109 @@
+package user
+
+import (
+	"testing"
+)
+
+func TestPasswds(t *testing.T) {
+	withRootDir(t, "testdata/root")
+	withProfile(t, Profile{})
+
+	var (
+		names = make(map[string]bool)
+		order []string
+	)
+	Passwds(func(pw *Passwd) bool {
+		if names[pw.Name] {
+			t.Fatalf("%s listed twice", pw.Name)
+		}
+		names[pw.Name] = true
+		order = append(order, pw.Name)
+		return true
+	})
+
+	for _, name := range []string{"root", "shell", "system_tvbox", "vendor_rfs", "oem_2900", "oem_5999", "u0_a0", "u0_a9999", "u0_i0", "u0_i9999"} {
+		if !names[name] {
+			t.Fatalf("%s not listed", name)
+		}
+	}
+
+	// OEM ids of the partition files aren't listed again.
+	for _, name := range []string{"oem_2903", "oem_2951", "u1_a0"} {
+		if names[name] {
+			t.Fatalf("%s listed", name)
+		}
+	}
+
+	var count = AndroidIDCount() + 4 + (100 - 2) + 1000 + 10000 + 10000
+	if len(order) != count || order[0] != "root" || order[len(order)-1] != "u0_i9999" {
+		t.Fatalf("listed %d entries from %s to %s, want %d", len(order), order[0], order[len(order)-1], count)
+	}
+
+	// fn returning false stops the enumeration.
+	count = 0
+	Passwds(func(pw *Passwd) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Fatalf("Passwds stopped after %d entries", count)
+	}
+}
+
+func TestGetpwent(t *testing.T) {
+	withRootDir(t, "testdata/root")
+	withProfile(t, Profile{API: 19})
+	defer Endpwent()
+
+	Setpwent()
+	var first = Getpwent()
+	if first == nil || first.Name != "root" {
+		t.Fatalf("Getpwent() = %+v", first)
+	}
+
+	var last *Passwd
+	for pw := Getpwent(); pw != nil; pw = Getpwent() {
+		last = pw
+	}
+	if last == nil || last.Name != "u0_i999" {
+		t.Fatalf("last Getpwent() = %+v", last)
+	}
+	if pw := Getpwent(); pw != nil {
+		t.Fatalf("Getpwent() after the end = %+v", pw)
+	}
+
+	Setpwent()
+	if pw := Getpwent(); pw == nil || pw.Name != "root" {
+		t.Fatalf("Getpwent() after Setpwent = %+v", pw)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	withRootDir(t, "testdata/root")
+	withProfile(t, Profile{})
+	defer Endgrent()
+
+	var names = make(map[string]bool)
+	Setgrent()
+	for group := Getgrent(); group != nil; group = Getgrent() {
+		names[group.Name] = true
+	}
+
+	for _, name := range []string{"root", "odm_hdmi", "oem_2952", "u0_a0", "u0_a0_cache", "u0_a0_ext", "u0_a0_ext_cache", "all_a0", "u0_i0"} {
+		if !names[name] {
+			t.Fatalf("%s not listed", name)
+		}
+	}
+	if names["oem_2903"] {
+		t.Fatalf("oem_2903 listed")
+	}
+
+	var count int
+	Groups(func(group *Group) bool {
+		count++
+		return true
+	})
+	if count != len(names) {
+		t.Fatalf("Groups listed %d entries, Getgrent %d", count, len(names))
+	}
+}
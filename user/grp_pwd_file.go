@@ -0,0 +1,193 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: grp_pwd_file.go
+ * @Package: user
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 10:12
+ */
+
+package user
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RootDir is the directory the partition files (passwd, group, ...) are
+// resolved against. It is "/" on a device, and can be pointed at an
+// extracted system image or a fixture tree to run the lookups on a host.
+var RootDir = "/"
+
+func rootPath(path string) string {
+	return filepath.Join(RootDir, path)
+}
+
+// passwd: name:password:uid:gid:gecos:dir:shell
+const passwdFields = 7
+
+// readDatabaseFile reads a passwd/group style file, splitting every line into
+// its colon separated fields. Lines with less than fields fields, and lines
+// whose name doesn't start with prefix are skipped.
+func readDatabaseFile(path, prefix string, fields int) (lines [][]string, err error) {
+	data, err := os.ReadFile(rootPath(path))
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSuffix(line, "\r"); line == "" || line[0] == '#' {
+			continue
+		}
+
+		var field = strings.SplitN(line, ":", fields)
+		if len(field) < fields {
+			continue
+		}
+
+		// To comply with Treble, users/groups from each partition need to be prefixed with
+		// the partition name.
+		if !strings.HasPrefix(field[0], prefix) {
+			continue
+		}
+
+		lines = append(lines, field)
+	}
+
+	return
+}
+
+func parseID(field string) (id uint32, ok bool) {
+	num, err := strconv.ParseUint(field, 10, 32)
+	if err != nil {
+		return
+	}
+
+	return uint32(num), true
+}
+
+func readPasswdFile(path, prefix string) (list []Passwd, err error) {
+	lines, err := readDatabaseFile(path, prefix, passwdFields)
+	if err != nil {
+		return
+	}
+
+	for _, field := range lines {
+		uid, ok := parseID(field[2])
+		if !ok {
+			continue
+		}
+
+		gid, ok := parseID(field[3])
+		if !ok {
+			continue
+		}
+
+		list = append(list, Passwd{
+			Name:  field[0],
+			UID:   uid,
+			GID:   gid,
+			Dir:   field[5],
+			Shell: field[6],
+		})
+	}
+
+	return
+}
+
+func findPasswd(match func(pw *Passwd) bool) *Passwd {
+	for _, file := range passwdFiles {
+		list, err := readPasswdFile(file[0], file[1])
+		if err != nil {
+			continue
+		}
+
+		for i := range list {
+			if match(&list[i]) {
+				return &list[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// Find a passwd entry by name from the partition passwd files.
+func findPasswdByName(name string) *Passwd {
+	return findPasswd(func(pw *Passwd) bool {
+		return pw.Name == name
+	})
+}
+
+// Find a passwd entry by uid from the partition passwd files.
+func findPasswdByID(uid uint32) *Passwd {
+	return findPasswd(func(pw *Passwd) bool {
+		return pw.UID == uid
+	})
+}
+
+// group: name:password:gid:members
+const groupFields = 4
+
+func readGroupFile(path, prefix string) (list []Group, err error) {
+	lines, err := readDatabaseFile(path, prefix, groupFields)
+	if err != nil {
+		return
+	}
+
+	for _, field := range lines {
+		gid, ok := parseID(field[2])
+		if !ok {
+			continue
+		}
+
+		var members []string
+		for _, member := range strings.Split(field[3], ",") {
+			if member = strings.TrimSpace(member); member != "" {
+				members = append(members, member)
+			}
+		}
+
+		list = append(list, Group{
+			Name:    field[0],
+			GID:     gid,
+			Members: members,
+		})
+	}
+
+	return
+}
+
+func findGroup(match func(group *Group) bool) *Group {
+	for _, file := range groupFiles {
+		list, err := readGroupFile(file[0], file[1])
+		if err != nil {
+			continue
+		}
+
+		for i := range list {
+			if match(&list[i]) {
+				return &list[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// Find a group entry by name from the partition group files.
+func findGroupByName(name string) *Group {
+	return findGroup(func(group *Group) bool {
+		return group.Name == name
+	})
+}
+
+// Find a group entry by gid from the partition group files.
+func findGroupByID(gid uint32) *Group {
+	return findGroup(func(group *Group) bool {
+		return group.GID == gid
+	})
+}
@@ -0,0 +1,124 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func withRootDir(t *testing.T, dir string) {
+	var old = RootDir
+	RootDir = dir
+	t.Cleanup(func() { RootDir = old })
+}
+
+func TestGetpwnamPartitionFile(t *testing.T) {
+	withRootDir(t, "testdata/root")
+
+	var tests = []struct {
+		name string
+		uid  uint32
+		dir  string
+	}{
+		{"system_tvbox", 6000, "/"},
+		{"vendor_qtidataservices", 2903, "/"},
+		{"vendor_rfs", 2951, "/data/vendor/rfs"},
+		{"odm_hdmi", 6500, "/"},
+	}
+
+	for _, test := range tests {
+		pw := Getpwnam(test.name)
+		if pw == nil {
+			t.Fatalf("Getpwnam(%q) = nil", test.name)
+		}
+		if pw.UID != test.uid || pw.GID != test.uid || pw.Dir != test.dir {
+			t.Fatalf("Getpwnam(%q) = %+v", test.name, *pw)
+		}
+
+		if pw = Getpwuid(test.uid); pw == nil || pw.Name != test.name {
+			t.Fatalf("Getpwuid(%d) = %+v, want %s", test.uid, pw, test.name)
+		}
+	}
+
+	// Names without the partition prefix and broken lines are ignored.
+	for _, name := range []string{"odm_misplaced", "vendor_broken"} {
+		if pw := Getpwnam(name); pw != nil {
+			t.Fatalf("Getpwnam(%q) = %+v, want nil", name, *pw)
+		}
+	}
+
+	// OEM ids without a file entry still resolve to oem_NNNN.
+	if pw := Getpwuid(2952); pw == nil || pw.Name != "oem_2952" {
+		t.Fatalf("Getpwuid(2952) = %+v", pw)
+	}
+}
+
+func TestGetgrnamPartitionFile(t *testing.T) {
+	withRootDir(t, "testdata/root")
+
+	var tests = []struct {
+		name    string
+		gid     uint32
+		members []string
+	}{
+		{"system_tvbox", 6000, []string{"system_tvbox"}},
+		{"vendor_qtidataservices", 2903, []string{"vendor_qtidataservices", "vendor_rfs"}},
+		{"vendor_rfs", 2951, nil},
+		{"odm_hdmi", 6500, []string{"odm_hdmi", "system_tvbox"}},
+	}
+
+	for _, test := range tests {
+		group := Getgrnam(test.name)
+		if group == nil {
+			t.Fatalf("Getgrnam(%q) = nil", test.name)
+		}
+		if group.GID != test.gid || fmt.Sprint(group.Members) != fmt.Sprint(test.members) {
+			t.Fatalf("Getgrnam(%q) = %+v", test.name, *group)
+		}
+
+		if group = Getgrgid(test.gid); group == nil || group.Name != test.name {
+			t.Fatalf("Getgrgid(%d) = %+v, want %s", test.gid, group, test.name)
+		}
+	}
+
+	for _, name := range []string{"odm_misplaced", "vendor_broken"} {
+		if group := Getgrnam(name); group != nil {
+			t.Fatalf("Getgrnam(%q) = %+v, want nil", name, *group)
+		}
+	}
+
+	// The oem_NNNN name still resolves for ids found in a group file.
+	if group := Getgrnam("oem_2903"); group == nil || group.Name != "vendor_qtidataservices" {
+		t.Fatalf("Getgrnam(oem_2903) = %+v", group)
+	}
+	if group := Getgrgid(2952); group == nil || group.Name != "oem_2952" {
+		t.Fatalf("Getgrgid(2952) = %+v", group)
+	}
+}
+
+func TestGetGroupList(t *testing.T) {
+	withRootDir(t, "testdata/root")
+
+	var tests = []struct {
+		user   string
+		group  uint32
+		groups []uint32
+	}{
+		{"system_tvbox", 6000, []uint32{6000, 6500}},
+		{"vendor_rfs", 2951, []uint32{2951, 2903}},
+		{"shell", AidShell, []uint32{AidShell}},
+	}
+
+	for _, test := range tests {
+		var groups = make([]uint32, 8)
+		ngroups, err := GetGroupList(test.user, test.group, groups)
+		if err != nil || fmt.Sprint(groups[:ngroups]) != fmt.Sprint(test.groups) {
+			t.Fatalf("GetGroupList(%q) = %v, %v, want %v", test.user, groups[:ngroups], err, test.groups)
+		}
+	}
+
+	var groups = make([]uint32, 1)
+	if ngroups, err := GetGroupList("system_tvbox", 6000, groups); ngroups != 2 || !errors.Is(err, ErrGroupListTruncated) || groups[0] != 6000 {
+		t.Fatalf("GetGroupList into a short list = %d, %v, %v", ngroups, err, groups)
+	}
+}
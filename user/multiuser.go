@@ -0,0 +1,98 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: multiuser.go
+ * @Package: user
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 21:30
+ */
+
+package user
+
+// UID is an Android uid or gid, made of the Android user id and the app id
+// as in libcutils multiuser.h: uid = user id * AidUserOffset + app id.
+type UID uint32
+
+// MultiuserGetUID returns the uid of the app appID for the Android user userID.
+func MultiuserGetUID(userID, appID uint32) UID {
+	return UID(userID*AidUserOffset + appID%AidUserOffset)
+}
+
+// UserID returns the Android user id of u.
+func (u UID) UserID() uint32 {
+	return uint32(u) / AidUserOffset
+}
+
+// AppID returns the app id of u, the uid it has for the first user.
+func (u UID) AppID() uint32 {
+	return uint32(u) % AidUserOffset
+}
+
+// IsApp reports whether u is the uid of an app.
+func (u UID) IsApp() bool {
+	var appid = u.AppID()
+	return appid >= AidAppStart && appid <= AidAppEnd
+}
+
+// IsIsolated reports whether u is the uid of an isolated process.
+func (u UID) IsIsolated() bool {
+	var r, ok = CurrentProfile().isolatedRange()
+	return ok && u.AppID() >= r.Start && u.AppID() <= r.End
+}
+
+// IsSdkSandbox reports whether u is the uid of a SDK sandbox process.
+func (u UID) IsSdkSandbox() bool {
+	var appid = u.AppID()
+	return appid >= AidSdkSandboxProcessStart && appid <= AidSdkSandboxProcessEnd
+}
+
+// IsSharedGid reports whether u is the gid apps share across the users,
+// which only exists for the first user.
+func (u UID) IsSharedGid() bool {
+	return u >= AidSharedGidStart && u <= AidSharedGidEnd
+}
+
+// appGid maps the app id of u into the per user range starting at start,
+// ok is false if u isn't an app.
+func (u UID) appGid(start uint32) (gid UID, ok bool) {
+	if !u.IsApp() {
+		return
+	}
+
+	return MultiuserGetUID(u.UserID(), u.AppID()-AidAppStart+start), true
+}
+
+// CacheGid returns the gid marking the cached data of the app u.
+func (u UID) CacheGid() (gid UID, ok bool) {
+	return u.appGid(AidCacheGidStart)
+}
+
+// ExtGid returns the gid marking the external data of the app u.
+func (u UID) ExtGid() (gid UID, ok bool) {
+	return u.appGid(AidExtGidStart)
+}
+
+// ExtCacheGid returns the gid marking the external cached data of the app u.
+func (u UID) ExtCacheGid() (gid UID, ok bool) {
+	return u.appGid(AidExtCacheGidStart)
+}
+
+// SdkSandboxUID returns the uid of the SDK sandbox process of the app u.
+func (u UID) SdkSandboxUID() (uid UID, ok bool) {
+	return u.appGid(AidSdkSandboxProcessStart)
+}
+
+// SharedAppGid returns the gid the app u shares across the users. Platform
+// uids share their own app id.
+func (u UID) SharedAppGid() (gid UID, ok bool) {
+	var appid = u.AppID()
+	switch {
+	case u.IsApp():
+		return UID(appid - AidAppStart + AidSharedGidStart), true
+	case appid < AidAppStart:
+		return UID(appid), true
+	}
+
+	return
+}
@@ -0,0 +1,49 @@
+package user
+
+import "testing"
+
+func TestUID(t *testing.T) {
+	withProfile(t, Profile{})
+
+	var uid = MultiuserGetUID(10, AidAppStart+48)
+	if uid != 1010048 || uid.UserID() != 10 || uid.AppID() != 10048 || !uid.IsApp() {
+		t.Fatalf("MultiuserGetUID(10, 10048) = %d", uid)
+	}
+
+	var tests = []struct {
+		get  func() (UID, bool)
+		want UID
+		ok   bool
+	}{
+		{uid.CacheGid, 1020048, true},
+		{uid.ExtGid, 1030048, true},
+		{uid.ExtCacheGid, 1040048, true},
+		{uid.SdkSandboxUID, 1020048, true},
+		{uid.SharedAppGid, 50048, true},
+		{UID(AidShell).SharedAppGid, AidShell, true},
+		{UID(AidShell).CacheGid, 0, false},
+		{UID(1099000).SharedAppGid, 0, false},
+	}
+
+	for i, test := range tests {
+		if got, ok := test.get(); got != test.want || ok != test.ok {
+			t.Fatalf("test %d = %d, %v, want %d, %v", i, got, ok, test.want, test.ok)
+		}
+	}
+
+	if !UID(1099000).IsIsolated() || UID(10048).IsIsolated() {
+		t.Fatal("IsIsolated")
+	}
+	if !UID(120000).IsSdkSandbox() || UID(110000).IsSdkSandbox() {
+		t.Fatal("IsSdkSandbox")
+	}
+	if !UID(50048).IsSharedGid() || UID(150048).IsSharedGid() {
+		t.Fatal("IsSharedGid")
+	}
+
+	// The isolated range follows the profile.
+	withProfile(t, Profile{API: 27})
+	if UID(90000).IsIsolated() || !UID(99000).IsIsolated() {
+		t.Fatal("IsIsolated on API 27")
+	}
+}
@@ -0,0 +1,136 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: osuser.go
+ * @Package: osuser
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 23:30
+ */
+
+// Package osuser provides the lookups of os/user backed by the Android id
+// scheme of package user, so code written against os/user finds the app
+// uids and the platform AIDs of an Android device.
+//
+// It is not a drop-in replacement: User is the User of os/user, whose
+// GroupIds method still asks the C library and misses the Android groups.
+// Callers must use the GroupIds function of this package instead.
+package osuser
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"strconv"
+
+	android "github.com/zooyer/android/user"
+)
+
+// The types and errors of os/user, so callers need a single import.
+type (
+	User                = user.User
+	Group               = user.Group
+	UnknownUserError    = user.UnknownUserError
+	UnknownUserIdError  = user.UnknownUserIdError
+	UnknownGroupError   = user.UnknownGroupError
+	UnknownGroupIdError = user.UnknownGroupIdError
+)
+
+func passwdToUser(pw *android.Passwd) *User {
+	return &User{
+		Uid:      strconv.FormatUint(uint64(pw.UID), 10),
+		Gid:      strconv.FormatUint(uint64(pw.GID), 10),
+		Username: pw.Name,
+		Name:     pw.Name,
+		HomeDir:  pw.Dir,
+	}
+}
+
+func groupToGroup(group *android.Group) *Group {
+	return &Group{
+		Gid:  strconv.FormatUint(uint64(group.GID), 10),
+		Name: group.Name,
+	}
+}
+
+// Current returns the current user.
+func Current() (*User, error) {
+	return LookupId(strconv.Itoa(os.Getuid()))
+}
+
+// Lookup looks up a user by username.
+func Lookup(username string) (*User, error) {
+	pw := android.Getpwnam(username)
+	if pw == nil {
+		return nil, UnknownUserError(username)
+	}
+
+	return passwdToUser(pw), nil
+}
+
+// LookupId looks up a user by userid.
+func LookupId(uid string) (*User, error) {
+	id, err := strconv.ParseUint(uid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	pw := android.Getpwuid(uint32(id))
+	if pw == nil {
+		return nil, UnknownUserIdError(int(id))
+	}
+
+	return passwdToUser(pw), nil
+}
+
+// LookupGroup looks up a group by name.
+func LookupGroup(name string) (*Group, error) {
+	group := android.Getgrnam(name)
+	if group == nil {
+		return nil, UnknownGroupError(name)
+	}
+
+	return groupToGroup(group), nil
+}
+
+// LookupGroupId looks up a group by groupid.
+func LookupGroupId(gid string) (*Group, error) {
+	id, err := strconv.ParseUint(gid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	group := android.Getgrgid(uint32(id))
+	if group == nil {
+		return nil, UnknownGroupIdError(gid)
+	}
+
+	return groupToGroup(group), nil
+}
+
+// GroupIds returns the list of group IDs that the user u is a member of,
+// its primary group first. Use it in place of the User.GroupIds method,
+// which doesn't know the Android groups.
+func GroupIds(u *User) ([]string, error) {
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups = make([]uint32, 16)
+	ngroups, err := android.GetGroupList(u.Username, uint32(gid), groups)
+	if errors.Is(err, android.ErrGroupListTruncated) {
+		groups = make([]uint32, ngroups)
+		ngroups, err = android.GetGroupList(u.Username, uint32(gid), groups)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ids = make([]string, 0, ngroups)
+	for _, group := range groups[:ngroups] {
+		ids = append(ids, strconv.FormatUint(uint64(group), 10))
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,51 @@
+package osuser
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	android "github.com/zooyer/android/user"
+)
+
+func TestLookup(t *testing.T) {
+	var old = android.RootDir
+	android.RootDir = "../testdata/root"
+	t.Cleanup(func() { android.RootDir = old })
+
+	u, err := Lookup("u0_a48")
+	if err != nil || u.Uid != "10048" || u.Gid != "10048" || u.HomeDir != "/data" {
+		t.Fatalf("Lookup(u0_a48) = %+v, %v", u, err)
+	}
+
+	if u, err = LookupId("2000"); err != nil || u.Username != "shell" {
+		t.Fatalf("LookupId(2000) = %+v, %v", u, err)
+	}
+
+	if u, err = LookupId("6000"); err != nil || u.Username != "system_tvbox" {
+		t.Fatalf("LookupId(6000) = %+v, %v", u, err)
+	}
+	if ids, err := GroupIds(u); err != nil || fmt.Sprint(ids) != "[6000 6500]" {
+		t.Fatalf("GroupIds(system_tvbox) = %v, %v", ids, err)
+	}
+
+	g, err := LookupGroup("all_a48")
+	if err != nil || g.Gid != "50048" {
+		t.Fatalf("LookupGroup(all_a48) = %+v, %v", g, err)
+	}
+	if g, err = LookupGroupId("1003"); err != nil || g.Name != "graphics" {
+		t.Fatalf("LookupGroupId(1003) = %+v, %v", g, err)
+	}
+
+	var unknownUser UnknownUserError
+	if _, err = Lookup("nobody_here"); !errors.As(err, &unknownUser) {
+		t.Fatalf("Lookup(nobody_here) = %v", err)
+	}
+	var unknownGroupId UnknownGroupIdError
+	if _, err = LookupGroupId("1099"); !errors.As(err, &unknownGroupId) {
+		t.Fatalf("LookupGroupId(1099) = %v", err)
+	}
+	if _, err = LookupId("x"); err == nil {
+		t.Fatal("LookupId(x) succeeded")
+	}
+}
@@ -0,0 +1,133 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: packages.go
+ * @Package: user
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 22:05
+ */
+
+package user
+
+import (
+	"os"
+	"strings"
+)
+
+// The list of the installed packages written by the PackageManagerService,
+// one package per line:
+//
+//	name uid debuggable data_dir seinfo gids [profileable version_code ...]
+//
+// gids is a comma separated list, or "none".
+const packagesListPath = "/data/system/packages.list"
+
+// LookupPackageNames makes Getpwnam resolve the name of an installed package,
+// such as "com.android.shell", to the uid of the app, the way run-as does.
+var LookupPackageNames bool
+
+// Package is an installed package of packages.list.
+type Package struct {
+	Name       string   `json:"name" yaml:"name"`             // package name
+	UID        uint32   `json:"uid" yaml:"uid"`               // app uid of the first user
+	Debuggable bool     `json:"debuggable" yaml:"debuggable"` // android:debuggable is set
+	DataDir    string   `json:"data_dir" yaml:"data_dir"`     // data directory of the first user
+	SeInfo     string   `json:"seinfo" yaml:"seinfo"`         // SELinux label info
+	GIDs       []uint32 `json:"gids" yaml:"gids"`             // supplementary gids of the permissions
+}
+
+// ParsePackagesList decodes the content of a packages.list file. Malformed
+// lines are skipped.
+func ParsePackagesList(data []byte) (packages []Package) {
+	for _, line := range strings.Split(string(data), "\n") {
+		var field = strings.Fields(line)
+		if len(field) < 6 {
+			continue
+		}
+
+		uid, ok := parseID(field[1])
+		if !ok {
+			continue
+		}
+
+		var pkg = Package{
+			Name:       field[0],
+			UID:        uid,
+			Debuggable: field[2] == "1",
+			DataDir:    field[3],
+			SeInfo:     field[4],
+		}
+
+		if field[5] != "none" {
+			for _, gid := range strings.Split(field[5], ",") {
+				if id, ok := parseID(gid); ok {
+					pkg.GIDs = append(pkg.GIDs, id)
+				}
+			}
+		}
+
+		packages = append(packages, pkg)
+	}
+
+	return
+}
+
+// ReadPackagesList reads and decodes the packages.list file path.
+func ReadPackagesList(path string) (packages []Package, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	return ParsePackagesList(data), nil
+}
+
+// Packages returns the packages installed on the device below RootDir.
+func Packages() ([]Package, error) {
+	return ReadPackagesList(rootPath(packagesListPath))
+}
+
+// LookupPackage returns the installed package name, nil if there's none.
+func LookupPackage(name string) *Package {
+	packages, _ := Packages()
+	for i := range packages {
+		if packages[i].Name == name {
+			return &packages[i]
+		}
+	}
+
+	return nil
+}
+
+// LookupPackagesByUID returns the installed packages running as uid. Apps
+// of a shared user id run as the same uid. The uid may be one of any user.
+func LookupPackagesByUID(uid uint32) (list []Package) {
+	var appid = UID(uid).AppID()
+
+	packages, _ := Packages()
+	for _, pkg := range packages {
+		if UID(pkg.UID).AppID() == appid {
+			list = append(list, pkg)
+		}
+	}
+
+	return
+}
+
+// packageToPasswd translates an installed package name into the passwd
+// entry of its app, with the data directory of the package as home.
+func packageToPasswd(name string) *Passwd {
+	var pkg = LookupPackage(name)
+	if pkg == nil {
+		return nil
+	}
+
+	var pw = Getpwuid(pkg.UID)
+	if pw == nil {
+		return nil
+	}
+	pw.Dir = pkg.DataDir
+
+	return pw
+}
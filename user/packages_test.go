@@ -0,0 +1,70 @@
+package user
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPackages(t *testing.T) {
+	withRootDir(t, "testdata/root")
+
+	packages, err := Packages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packages) != 5 {
+		t.Fatalf("Packages() = %+v", packages)
+	}
+
+	var pkg = LookupPackage("com.example.debug")
+	if pkg == nil || pkg.UID != 10102 || !pkg.Debuggable || pkg.GIDs != nil || pkg.SeInfo != "default:targetSdkVersion=29" {
+		t.Fatalf("LookupPackage(com.example.debug) = %+v", pkg)
+	}
+	if pkg = LookupPackage("com.android.shell"); pkg == nil || len(pkg.GIDs) != 8 || pkg.GIDs[1] != AidLog {
+		t.Fatalf("LookupPackage(com.android.shell) = %+v", pkg)
+	}
+	if pkg = LookupPackage("com.example.baduid"); pkg != nil {
+		t.Fatalf("LookupPackage(com.example.baduid) = %+v", pkg)
+	}
+
+	// Shared user ids, in any Android user.
+	var names []string
+	for _, pkg := range LookupPackagesByUID(1010103) {
+		names = append(names, pkg.Name)
+	}
+	if fmt.Sprint(names) != "[com.example.shared.one com.example.shared.two]" {
+		t.Fatalf("LookupPackagesByUID(1010103) = %v", names)
+	}
+}
+
+func TestGetpwnamPackage(t *testing.T) {
+	withRootDir(t, "testdata/root")
+
+	if pw := Getpwnam("com.dangbei.tvlauncher"); pw != nil {
+		t.Fatalf("Getpwnam(com.dangbei.tvlauncher) = %+v without LookupPackageNames", *pw)
+	}
+
+	LookupPackageNames = true
+	defer func() { LookupPackageNames = false }()
+
+	var tests = []struct {
+		name string
+		uid  uint32
+		pw   string
+		dir  string
+	}{
+		{"com.dangbei.tvlauncher", 10048, "u0_a48", "/data/user/0/com.dangbei.tvlauncher"},
+		{"com.android.shell", AidShell, "shell", "/data/user_de/0/com.android.shell"},
+	}
+
+	for _, test := range tests {
+		pw := Getpwnam(test.name)
+		if pw == nil || pw.UID != test.uid || pw.Name != test.pw || pw.Dir != test.dir {
+			t.Fatalf("Getpwnam(%q) = %+v", test.name, pw)
+		}
+	}
+
+	if pw := Getpwnam("com.not.installed"); pw != nil {
+		t.Fatalf("Getpwnam(com.not.installed) = %+v", *pw)
+	}
+}
@@ -0,0 +1,262 @@
+/**
+ * @Author: zzy
+ * @Email: zhangzhongyuan@didiglobal.com
+ * @Description:
+ * @File: profile.go
+ * @Package: user
+ * @Version: 1.0.0
+ * @Date: 2026/10/18 16:40
+ */
+
+package user
+
+import (
+	"sync"
+
+	"github.com/zooyer/android/property"
+)
+
+// Profile selects the Android release the lookups are made for, so names
+// reflect what a device of that release supports. The zero Profile is the
+// newest release known to the package.
+type Profile struct {
+	API      int // API level of the Android release, 0 means the newest
+	FirstAPI int // ro.product.first_api_level of the device, 0 means unknown
+}
+
+// The profile used by all lookups of the package, which may run
+// concurrently with SetProfile.
+var (
+	profileMutex sync.RWMutex
+	profile      Profile
+)
+
+// SetProfile sets the profile used by all lookups of the package.
+func SetProfile(p Profile) {
+	profileMutex.Lock()
+	defer profileMutex.Unlock()
+
+	profile = p
+}
+
+// CurrentProfile returns the profile used by all lookups of the package.
+func CurrentProfile() Profile {
+	profileMutex.RLock()
+	defer profileMutex.RUnlock()
+
+	return profile
+}
+
+// The API level in which the platform AIDs first appeared. AIDs not listed
+// here predate Android 4.0 (API 14), the oldest release profiled.
+var aidSince = map[uint32]int{
+	AidDaemon:                29,
+	AIdBin:                   29,
+	AidMediaRW:               16,
+	AidSdcardR:               16,
+	AidClat:                  18,
+	AidLoopRadio:             18,
+	AidMediaDrm:              18,
+	AidPackageInfo:           19,
+	AidSdcardPics:            19,
+	AidSdcardAV:              19,
+	AidSdcardAll:             19,
+	AidLogd:                  21,
+	AidSharedRELRO:           21,
+	AidDbus:                  23,
+	AidTlsdate:               23,
+	AidMediaEx:               24,
+	AidAudioServer:           24,
+	AidMetricsColl:           24,
+	AidMetricSD:              24,
+	AidWebServ:               24,
+	AidDebugGerd:             24,
+	AidMediaCodec:            24,
+	AidCameraServer:          24,
+	AidFirewall:              24,
+	AidTrunks:                24,
+	AidNVRAM:                 25,
+	AidDns:                   25,
+	AidDnsTether:             25,
+	AidWebviewZygote:         26,
+	AidVehicleNetwork:        26,
+	AidMediaAudio:            26,
+	AidMediaVideo:            26,
+	AidMediaImage:            26,
+	AidTombstoned:            26,
+	AidMediaOBB:              26,
+	AidESE:                   26,
+	AidOTAUpdate:             26,
+	AidAutomotiveEvs:         27,
+	AidLoWPAN:                27,
+	AidHsm:                   27,
+	AidReservedDisk:          28,
+	AidStatsd:                28,
+	AidIncidentd:             28,
+	AidSecureElement:         28,
+	AidLMKD:                  29,
+	AidLLKD:                  29,
+	AidIORAPD:                29,
+	AidGPUService:            29,
+	AidNetworkStack:          29,
+	AidGSID:                  29,
+	AidFSVerityCert:          30,
+	AidCredStore:             30,
+	AidExternalStorage:       30,
+	AidExtDataRW:             30,
+	AidExtObbRW:              30,
+	AidContextHub:            31,
+	AidVirtualizationService: 31,
+	AidARTD:                  33,
+	AidUWB:                   33,
+	AidThreadNetwork:         33,
+	AidDiced:                 33,
+	AidDmesgd:                33,
+	AidJcWeaver:              33,
+	AidJcStrongbox:           33,
+	AidJcIdentityCred:        33,
+	AidSDKSandbox:            33,
+	AidSecurityLogWriter:     33,
+	AidNetBtStack:            17,
+	AidReadProc:              24,
+	AidWakelock:              26,
+	AidUhid:                  28,
+	AidReadTracefs:           33,
+	AidEverybody:             23,
+}
+
+// DetectProfile builds the profile of the device below RootDir from its
+// ro.build.version.sdk and ro.product.first_api_level properties.
+func DetectProfile() Profile {
+	props, _ := property.Load(RootDir)
+
+	return Profile{
+		API:      props.GetInt("ro.build.version.sdk", 0),
+		FirstAPI: props.GetInt("ro.product.first_api_level", 0),
+	}
+}
+
+// atLeast reports whether the profiled release has API level api.
+func (p Profile) atLeast(api int) bool {
+	return p.API == 0 || p.API >= api
+}
+
+// hasID reports whether the platform AID id exists in the profiled release.
+func (p Profile) hasID(id uint32) bool {
+	return p.atLeast(aidSince[id])
+}
+
+// AndroidIDs returns the platform AIDs which exist in the profiled release.
+func (p Profile) AndroidIDs() []AndroidIDInfo {
+	var ids = make([]AndroidIDInfo, 0, len(AndroidIDs))
+	for _, info := range AndroidIDs {
+		if p.hasID(info.Aid) {
+			ids = append(ids, info)
+		}
+	}
+
+	return ids
+}
+
+// isolatedRange returns the isolated process range of the profiled release.
+// Isolated processes came with API 16, at 99000, the range grew down to
+// 90000 with API 28.
+func (p Profile) isolatedRange() (r IDRange, ok bool) {
+	switch {
+	case p.atLeast(28):
+		return IDRange{AidIsolatedStart, AidIsolatedEnd}, true
+	case p.atLeast(16):
+		return IDRange{99000, AidIsolatedEnd}, true
+	}
+
+	return
+}
+
+// isolatedStart returns the first isolated uid of the profiled release, or
+// AidUserOffset if it has no isolated processes.
+func (p Profile) isolatedStart() uint32 {
+	if r, ok := p.isolatedRange(); ok {
+		return r.Start
+	}
+
+	return AidUserOffset
+}
+
+func (p Profile) userRanges() []IDRange {
+	if p.API == 0 {
+		return userRanges
+	}
+
+	var ranges = []IDRange{{AidAppStart, AidAppEnd}}
+	if r, ok := p.isolatedRange(); ok {
+		ranges = append(ranges, r)
+	}
+
+	return ranges
+}
+
+func (p Profile) groupRanges() []IDRange {
+	if p.API == 0 {
+		return groupRanges
+	}
+
+	var ranges = []IDRange{{AidAppStart, AidAppEnd}}
+	if p.atLeast(26) {
+		ranges = append(ranges, IDRange{AidCacheGidStart, AidCacheGidEnd})
+	}
+	if p.atLeast(28) {
+		ranges = append(ranges, IDRange{AidExtGidStart, AidExtGidEnd})
+		ranges = append(ranges, IDRange{AidExtCacheGidStart, AidExtCacheGidEnd})
+	}
+	if p.atLeast(17) {
+		ranges = append(ranges, IDRange{AidSharedGidStart, AidSharedGidEnd})
+	}
+	if r, ok := p.isolatedRange(); ok {
+		ranges = append(ranges, r)
+	}
+
+	return ranges
+}
+
+// UserRanges returns the reserved app uid ranges of the first user in the
+// profiled release. They repeat every AidUserOffset for the other users.
+func UserRanges() []IDRange {
+	return append([]IDRange(nil), CurrentProfile().userRanges()...)
+}
+
+// GroupRanges returns the reserved app gid ranges of the first user in the
+// profiled release.
+func GroupRanges() []IDRange {
+	return append([]IDRange(nil), CurrentProfile().groupRanges()...)
+}
+
+// OemRanges returns the OEM reserved id ranges of the profiled release.
+func OemRanges() []IDRange {
+	return CurrentProfile().oemRanges()
+}
+
+// oemRanges returns the OEM reserved ranges of the profiled release.
+func (p Profile) oemRanges() (ranges []IDRange) {
+	if p.atLeast(21) {
+		ranges = append(ranges, IDRange{AidOemReservedStart, AidOemReservedEnd})
+	}
+	if p.atLeast(26) {
+		ranges = append(ranges, IDRange{AidOemReserved2Start, AidOemReserved2End})
+	}
+
+	return
+}
+
+// launchedBeforeApi29 reports whether the profiled device launched before
+// API 29 (Q), ok is false if the profile doesn't tell.
+func (p Profile) launchedBeforeApi29() (before bool, ok bool) {
+	switch {
+	case p.FirstAPI != 0:
+		return p.FirstAPI < 29, true
+	case p.API != 0:
+		// A device running an older release has launched with it or earlier.
+		return p.API < 29, p.API < 29
+	}
+
+	return
+}
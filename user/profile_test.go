@@ -0,0 +1,135 @@
+package user
+
+import "testing"
+
+func withProfile(t *testing.T, p Profile) {
+	var old = CurrentProfile()
+	SetProfile(p)
+	t.Cleanup(func() { SetProfile(old) })
+}
+
+func TestProfileAndroidIDs(t *testing.T) {
+	withProfile(t, Profile{API: 19})
+
+	for _, name := range []string{"webview_zygote", "network_stack", "audioserver"} {
+		if pw := Getpwnam(name); pw != nil {
+			t.Fatalf("Getpwnam(%q) = %+v on API 19", name, *pw)
+		}
+	}
+	for _, name := range []string{"sdcard_all", "package_info", "shell"} {
+		if pw := Getpwnam(name); pw == nil {
+			t.Fatalf("Getpwnam(%q) = nil on API 19", name)
+		}
+	}
+
+	if count := len(CurrentProfile().AndroidIDs()); count >= AndroidIDCount() {
+		t.Fatalf("API 19 has %d ids, newest release has %d", count, AndroidIDCount())
+	}
+	if count := len(Profile{}.AndroidIDs()); count != AndroidIDCount() {
+		t.Fatalf("newest release has %d ids, want %d", count, AndroidIDCount())
+	}
+}
+
+func TestProfileRanges(t *testing.T) {
+	withProfile(t, Profile{})
+
+	var tests = []struct {
+		profile Profile
+		uid     uint32
+		name    string
+	}{
+		{Profile{}, 90000, "u0_i0"},
+		{Profile{API: 28}, 90000, "u0_i0"},
+		{Profile{API: 27}, 90000, ""},
+		{Profile{API: 27}, 99005, "u0_i5"},
+		{Profile{API: 15}, 99005, ""},
+		{Profile{API: 15}, 10048, "u0_a48"},
+	}
+
+	for _, test := range tests {
+		SetProfile(test.profile)
+
+		var name string
+		if pw := Getpwuid(test.uid); pw != nil {
+			name = pw.Name
+		}
+		if name != test.name {
+			t.Fatalf("%+v: Getpwuid(%d) = %q, want %q", test.profile, test.uid, name, test.name)
+		}
+
+		if test.name == "" {
+			continue
+		}
+		if pw := Getpwnam(test.name); pw == nil || pw.UID != test.uid {
+			t.Fatalf("%+v: Getpwnam(%q) = %+v, want %d", test.profile, test.name, pw, test.uid)
+		}
+	}
+
+	SetProfile(Profile{API: 25})
+	if group := Getgrgid(AidExtGidStart + 48); group != nil {
+		t.Fatalf("Getgrgid(%d) = %+v on API 25", AidExtGidStart+48, *group)
+	}
+	if group := Getgrnam("all_a48"); group == nil || group.GID != AidSharedGidStart+48 {
+		t.Fatalf("Getgrnam(all_a48) = %+v on API 25", group)
+	}
+}
+
+func TestProfileLaunchedBeforeApi29(t *testing.T) {
+	withRootDir(t, "testdata/root")
+
+	withProfile(t, Profile{API: 30, FirstAPI: 28})
+	if pw := Getpwuid(3500); pw == nil || pw.Name != "oem_3500" {
+		t.Fatalf("Getpwuid(3500) = %+v, want oem_3500", pw)
+	}
+
+	SetProfile(Profile{API: 30, FirstAPI: 30})
+	if pw := Getpwuid(3500); pw != nil {
+		t.Fatalf("Getpwuid(3500) = %+v, want nil", *pw)
+	}
+}
+
+func TestDetectProfile(t *testing.T) {
+	withRootDir(t, "testdata/root")
+	withProfile(t, Profile{})
+
+	if p := DetectProfile(); p != (Profile{API: 29, FirstAPI: 28}) {
+		t.Fatalf("DetectProfile() = %+v", p)
+	}
+
+	// Without a profile the first API level comes from the property files.
+	if !deviceLaunchedBeforeApi29() {
+		t.Fatal("deviceLaunchedBeforeApi29() = false, want true")
+	}
+	if pw := Getpwuid(3500); pw == nil || pw.Name != "oem_3500" {
+		t.Fatalf("Getpwuid(3500) = %+v, want oem_3500", pw)
+	}
+
+	// The cached level is read again below another RootDir.
+	RootDir = t.TempDir()
+	if deviceLaunchedBeforeApi29() {
+		t.Fatal("deviceLaunchedBeforeApi29() = true without property files")
+	}
+	RootDir = "testdata/root"
+	if !deviceLaunchedBeforeApi29() {
+		t.Fatal("deviceLaunchedBeforeApi29() = false after restoring RootDir")
+	}
+}
+
+func TestProfileConcurrent(t *testing.T) {
+	withProfile(t, Profile{})
+
+	var done = make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			SetProfile(Profile{API: 19 + i%15})
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		_ = Getpwnam("u10_a12")
+		_ = Getgrgid(AidShell)
+		_ = GroupRanges()
+	}
+	<-done
+}
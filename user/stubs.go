@@ -1,26 +1,29 @@
 package user
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"os"
 	"strconv"
 	"strings"
-	"syscall"
-	"unicode"
+	"sync"
+
+	"github.com/zooyer/android/property"
 )
 
 type Group struct {
-	Name string
-	GID  uint32
+	Name    string   `json:"name" yaml:"name"`       // group name
+	GID     uint32   `json:"gid" yaml:"gid"`         // numerical group ID
+	Members []string `json:"members" yaml:"members"` // names of the group members
 }
 
 type Passwd struct {
-	Name  string // user's login name
-	UID   uint32 // numerical user ID
-	GID   uint32 // numerical group ID
-	Dir   string // initial working directory
-	Shell string // program to use as shell
+	Name  string `json:"name" yaml:"name"`   // user's login name
+	UID   uint32 `json:"uid" yaml:"uid"`     // numerical user ID
+	GID   uint32 `json:"gid" yaml:"gid"`     // numerical group ID
+	Dir   string `json:"dir" yaml:"dir"`     // initial working directory
+	Shell string `json:"shell" yaml:"shell"` // program to use as shell
 }
 
 var passwdFiles = [][]string{
@@ -39,37 +42,35 @@ var groupFiles = [][]string{
 	{"/system_ext/etc/group", "system_ext_"},
 }
 
-func strtoul(str string, base int, bit int) (num uint32, end string, err error) {
-	var (
-		i int
-		c rune
-		s = []rune(str)
-	)
+var (
+	ErrUnknownName  = errors.New("unknown user or group name")
+	ErrOutOfRange   = errors.New("id out of range")
+	ErrUserOverflow = errors.New("user id overflows")
+)
 
-	var isBreak bool
-	for i, c = range s {
-		if !unicode.IsNumber(c) {
-			isBreak = true
-			break
-		}
+// parseNumber splits the leading ASCII decimal number off str, a part of
+// the user/group name.
+func parseNumber(name, str string) (num uint32, end string, err error) {
+	var i int
+	for i < len(str) && str[i] >= '0' && str[i] <= '9' {
+		i++
 	}
 
-	if isBreak {
-		end = string(s[i:])
-	} else {
-		i++
+	if i == 0 {
+		return 0, str, fmt.Errorf("%w: %q", ErrUnknownName, name)
 	}
 
-	u, err := strconv.ParseUint(string(s[:i]), base, bit)
+	n, err := strconv.ParseUint(str[:i], 10, 32)
 	if err != nil {
-		return
+		return 0, str, fmt.Errorf("%w: %q", ErrOutOfRange, name)
 	}
 
-	return uint32(u), end, nil
+	return uint32(n), str[i:], nil
 }
 
-func isDigit(str string, index int) bool {
-	return unicode.IsDigit([]rune(str)[index])
+// startsWithNumber reports whether str is a letter followed by an ASCII digit.
+func startsWithNumber(str string, letter byte) bool {
+	return len(str) > 1 && str[0] == letter && str[1] >= '0' && str[1] <= '9'
 }
 
 func androidIInfoToPasswd(info AndroidIDInfo) *Passwd {
@@ -96,7 +97,7 @@ func androidIInfoToGroup(info AndroidIDInfo) *Group {
 
 func findAndroidIDInfoByID(id uint32) *AndroidIDInfo {
 	for _, aid := range AndroidIDs {
-		if aid.Aid == id {
+		if aid.Aid == id && CurrentProfile().hasID(aid.Aid) {
 			return &aid
 		}
 	}
@@ -106,7 +107,7 @@ func findAndroidIDInfoByID(id uint32) *AndroidIDInfo {
 
 func findAndroidIDInfoByName(name string) *AndroidIDInfo {
 	for _, aid := range AndroidIDs {
-		if aid.Name == name {
+		if aid.Name == name && CurrentProfile().hasID(aid.Aid) {
 			return &aid
 		}
 	}
@@ -117,16 +118,17 @@ func findAndroidIDInfoByName(name string) *AndroidIDInfo {
 // These are a list of the reserved app ranges, and should never contain anything below
 // AID_APP_START.  They exist per user, so a given uid/gid modulo AID_USER_OFFSET will map
 // to these ranges.
-type idRange struct {
-	Start, End uint32
+type IDRange struct {
+	Start uint32 `json:"start" yaml:"start"`
+	End   uint32 `json:"end" yaml:"end"`
 }
 
-var userRanges = []idRange{
+var userRanges = []IDRange{
 	{AidAppStart, AidAppEnd},
 	{AidIsolatedStart, AidIsolatedEnd},
 }
 
-var groupRanges = []idRange{
+var groupRanges = []IDRange{
 	{AidAppStart, AidAppEnd},
 	{AidCacheGidStart, AidCacheGidEnd},
 	{AidExtGidStart, AidExtGidEnd},
@@ -135,7 +137,7 @@ var groupRanges = []idRange{
 	{AidIsolatedStart, AidIsolatedEnd},
 }
 
-func verifyUserRangesAscending[T []idRange](ranges T) bool {
+func verifyUserRangesAscending[T []IDRange](ranges T) bool {
 	if len(ranges) < 2 {
 		return false
 	}
@@ -183,7 +185,7 @@ func platformIDSecondaryUserAllowed(id uint32) bool {
 }
 
 func isValidAppID(id uint32, isGroup bool) bool {
-	var appid = id % AidUserOffset
+	var appid = UID(id).AppID()
 
 	// AidOverFlowUid is never a valid app id, so we explicitly return false to ensure this.
 	// This is true across all users, as there is no reason to ever map this id into any user range.
@@ -191,9 +193,10 @@ func isValidAppID(id uint32, isGroup bool) bool {
 		return false
 	}
 
-	var ranges = userRanges
+	var p = CurrentProfile()
+	var ranges = p.userRanges()
 	if isGroup {
-		ranges = groupRanges
+		ranges = p.groupRanges()
 	}
 
 	// If we're checking an appid that resolves below the user range, then it's a platform AID for a
@@ -203,7 +206,7 @@ func isValidAppID(id uint32, isGroup bool) bool {
 	}
 
 	// The shared GID range is only valid for the first user.
-	if appid >= AidSharedGidStart && appid <= AidSharedGidEnd && appid != id {
+	if UID(appid).IsSharedGid() && appid != id {
 		return false
 	}
 
@@ -219,9 +222,10 @@ func isValidAppID(id uint32, isGroup bool) bool {
 
 // This provides an iterator for app_ids within the first user's app id's.
 func getNextAppID(currentID uint32, isGroup bool) uint32 {
-	var ranges = userRanges
+	var p = CurrentProfile()
+	var ranges = p.userRanges()
 	if isGroup {
-		ranges = groupRanges
+		ranges = p.groupRanges()
 	}
 
 	// If current_id is below the first of the ranges, then we're uninitialized, and return the first
@@ -257,127 +261,114 @@ func getNextAppID(currentID uint32, isGroup bool) uint32 {
 // u0_a1234 -> 0 * AID_USER_OFFSET + AID_APP_START + 1234
 // u2_i1000 -> 2 * AID_USER_OFFSET + AID_ISOLATED_START + 1000
 // u1_system -> 1 * AID_USER_OFFSET + android_ids['system']
-// returns 0 and sets errno to ENOENT in case of error.
+// app_1234 -> AID_APP + 1234 (legacy names from before multi-user)
+// returns ErrUnknownName, ErrOutOfRange or ErrUserOverflow in case of error.
 func appIDFromName(name string, isGroup bool) (id uint32, err error) {
+	var (
+		unknown    = fmt.Errorf("%w: %q", ErrUnknownName, name)
+		outOfRange = fmt.Errorf("%w: %q", ErrOutOfRange, name)
+	)
+
+	if rest := strings.TrimPrefix(name, "app_"); rest != name {
+		appid, end, err := parseNumber(name, rest)
+		if err != nil {
+			return 0, err
+		}
+		if end != "" {
+			return 0, unknown
+		}
+
+		// Check that the value can be stored in our 32-bit uid_t/gid_t.
+		if appid > math.MaxUint32-AidApp {
+			return 0, outOfRange
+		}
+
+		return AidApp + appid, nil
+	}
+
 	var (
 		end         string
 		isSharedGid bool
 		userid      uint32
 	)
 
-	if isGroup && len(name) > 2 && name[:3] == "all" {
-		end = name[3:]
-		userid = 0
+	if isGroup && strings.HasPrefix(name, "all_") {
+		end = name[len("all_"):]
 		isSharedGid = true
-	} else if len(name) > 1 && name[0] == 'u' && isDigit(name, 1) {
-		if userid, end, err = strtoul(name[1:], 10, 32); err != nil {
+	} else if startsWithNumber(name, 'u') {
+		if userid, end, err = parseNumber(name, name[1:]); err != nil {
 			return
 		}
+		if end = strings.TrimPrefix(end, "_"); end == "" {
+			return 0, unknown
+		}
 	} else {
-		err = syscall.ENOENT
-		return
-	}
-
-	if !strings.HasPrefix(end, "_") || end[1:] == "" {
-		err = syscall.ENOENT
-		return
+		return 0, unknown
 	}
 
 	var appid uint32
-	if end[1] == 'a' && len(end) > 2 && isDigit(end, 2) {
-		if isSharedGid {
-			// end will point to \0 if the strtoul below succeeds.
-			if appid, end, err = strtoul(end[2:], 10, 32); err != nil {
-				return
-			}
+	if startsWithNumber(end, 'a') {
+		if appid, end, err = parseNumber(name, end[1:]); err != nil {
+			return
+		}
+		if appid >= AidUserOffset {
+			return 0, outOfRange
+		}
+
+		switch {
+		case isSharedGid:
 			if appid += AidSharedGidStart; appid > AidSharedGidEnd {
-				err = syscall.ENOENT
-				return
-			}
-		} else {
-			// end will point to \0 if the strtoul below succeeds.
-			if appid, end, err = strtoul(end[2:], 10, 32); err != nil {
-				return
-			}
-			if isGroup {
-				if end == "_ext_cache" {
-					end = end[10:]
-					appid += AidExtCacheGidStart
-				} else if end == "_ext" {
-					end = end[4:]
-					appid += AidExtGidStart
-				} else if end == "_cache" {
-					end = end[6:]
-					appid += AidCacheGidStart
-				} else {
-					appid += AidAppStart
-				}
-			} else {
-				appid += AidAppStart
+				return 0, outOfRange
 			}
+		case isGroup && end == "_ext_cache":
+			end, appid = "", appid+AidExtCacheGidStart
+		case isGroup && end == "_ext":
+			end, appid = "", appid+AidExtGidStart
+		case isGroup && end == "_cache":
+			end, appid = "", appid+AidCacheGidStart
+		default:
+			appid += AidAppStart
 		}
-	} else if end[1] == 'i' && len(end) > 2 && isDigit(end, 2) {
-		// end will point to \0 if the strtoul below succeeds.
-		if appid, end, err = strtoul(end[2:], 10, 32); err != nil {
+	} else if isSharedGid {
+		return 0, unknown
+	} else if startsWithNumber(end, 'i') {
+		if appid, end, err = parseNumber(name, end[1:]); err != nil {
 			return
 		}
-		appid += AidIsolatedStart
-	} else if info := findAndroidIDInfoByName(end[1:]); info != nil {
-		appid = info.Aid
-		end = end[len(info.Name):]
+		if appid >= AidUserOffset {
+			return 0, outOfRange
+		}
+		appid += CurrentProfile().isolatedStart()
+	} else if info := findAndroidIDInfoByName(end); info != nil {
+		end, appid = "", info.Aid
 	}
 
 	// Check that the entire string was consumed by one of the 3 cases above.
 	if end != "" {
-		err = syscall.ENOENT
-		return
+		return 0, unknown
 	}
 
 	// Check that user id won't overflow.
 	if userid > 1000 {
-		err = syscall.ENOENT
-		return
+		return 0, fmt.Errorf("%w: %q", ErrUserOverflow, name)
 	}
 
 	// Check that app id is within range.
 	if appid >= AidUserOffset {
-		err = syscall.ENOENT
-		return
+		return 0, outOfRange
 	}
 
-	return appid + userid*AidUserOffset, nil
-
-	var i uint64
-	if !strings.HasPrefix(name, "app_") || unicode.IsDigit(rune(name[4])) {
-		goto FAIL
-	}
-
-	if i, err = strconv.ParseUint(name[4:], 10, 32); err != nil {
-		return
-	}
-
-	i += AidApp
-
-	/* check for overflow and that the value can be
-	 * stored in our 32-bit uid_t/gid_t */
-	if i < AidApp || uint64(uint(i)) != i {
-		goto FAIL
-	}
-
-	return uint32(i), nil
-
-FAIL:
-	return 0, syscall.ENOENT
+	return uint32(MultiuserGetUID(userid, appid)), nil
 }
 
 func printAppNameFromUid(uid uint32) string {
 	var (
-		appid  = uid % AidUserOffset
-		userid = uid / AidUserOffset
+		appid  = UID(uid).AppID()
+		userid = UID(uid).UserID()
 	)
 
-	if appid >= AidIsolatedStart {
-		return fmt.Sprintf("u%d_i%d", userid, appid-AidIsolatedStart)
+	if isolatedStart := CurrentProfile().isolatedStart(); appid >= isolatedStart {
+		return fmt.Sprintf("u%d_i%d", userid, appid-isolatedStart)
 	} else if appid < AidAppStart {
 		if info := findAndroidIDInfoByID(appid); info != nil {
 			return fmt.Sprintf("u%d_%s", userid, info.Name)
@@ -391,13 +382,13 @@ func printAppNameFromUid(uid uint32) string {
 
 func printAppNameFromGid(gid uint32) string {
 	var (
-		appid  = gid % AidUserOffset
-		userid = gid / AidUserOffset
+		appid  = UID(gid).AppID()
+		userid = UID(gid).UserID()
 	)
 
-	if appid >= AidIsolatedStart {
-		return fmt.Sprintf("u%d_i%d", userid, appid-AidIsolatedStart)
-	} else if userid == 0 && appid >= AidSharedGidStart && appid <= AidSharedGidEnd {
+	if isolatedStart := CurrentProfile().isolatedStart(); appid >= isolatedStart {
+		return fmt.Sprintf("u%d_i%d", userid, appid-isolatedStart)
+	} else if UID(gid).IsSharedGid() {
 		return fmt.Sprintf("all_a%d", appid-AidSharedGidStart)
 	} else if appid >= AidExtCacheGidStart && appid <= AidExtCacheGidEnd {
 		return fmt.Sprintf("u%d_a%d_ext_cache", userid, appid-AidExtCacheGidStart)
@@ -416,25 +407,49 @@ func printAppNameFromGid(gid uint32) string {
 	return ""
 }
 
-// TODO implement
+// The first API level cached by deviceLaunchedBeforeApi29, and the RootDir
+// it was read below, so it's read again once RootDir changes.
+var (
+	firstApiLevelMutex sync.RWMutex
+	firstApiLevelRoot  *string
+	firstApiLevel      int
+)
+
+// cachedFirstApiLevel returns ro.product.first_api_level below RootDir,
+// reading the property files first if needed.
+func cachedFirstApiLevel() int {
+	var root = RootDir
+
+	firstApiLevelMutex.RLock()
+	var level, ok = firstApiLevel, firstApiLevelRoot != nil && *firstApiLevelRoot == root
+	firstApiLevelMutex.RUnlock()
+	if ok {
+		return level
+	}
+
+	firstApiLevelMutex.Lock()
+	defer firstApiLevelMutex.Unlock()
+
+	if firstApiLevelRoot == nil || *firstApiLevelRoot != root {
+		props, _ := property.Load(root)
+		firstApiLevel, firstApiLevelRoot = props.GetInt("ro.product.first_api_level", 0), &root
+	}
+
+	return firstApiLevel
+}
+
 func deviceLaunchedBeforeApi29() bool {
-	/*
-	  // Check if ro.product.first_api_level is set to a value > 0 and < 29, if so, this device was
-	  // launched before API 29 (Q). Any other value is considered to be either in development or
-	  // launched after.
-	  // Cache the value as __system_property_get() is expensive and this may be called often.
-	  static bool result = [] {
-	    char value[PROP_VALUE_MAX] = { 0 };
-	    if (__system_property_get("ro.product.first_api_level", value) == 0) {
-	      return false;
-	    }
-	    int value_int = atoi(value);
-	    return value_int != 0 && value_int < 29;
-	  }();
-	  return result;
-	*/
+	if before, ok := CurrentProfile().launchedBeforeApi29(); ok {
+		return before
+	}
 
-	return false
+	// Check if ro.product.first_api_level is set to a value > 0 and < 29, if so, this device was
+	// launched before API 29 (Q). Any other value is considered to be either in development or
+	// launched after.
+	// Cache the value as reading the property files is expensive and this may be called often.
+	var value = cachedFirstApiLevel()
+
+	return value != 0 && value < 29
 }
 
 // oem_XXXX -> uid
@@ -447,7 +462,13 @@ func isOemID(id uint32) bool {
 		return true
 	}
 
-	return id >= AidOemReservedStart && id <= AidOemReservedEnd || id >= AidOemReserved2Start && id <= AidOemReserved2End
+	for _, r := range CurrentProfile().oemRanges() {
+		if id >= r.Start && id <= r.End {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Translate an OEM name to the corresponding user/group id.
@@ -464,16 +485,19 @@ func oemIDFromName(name string) (id uint32) {
 }
 
 func oemIDToPasswd(uid uint32) *Passwd {
-	for _, file := range passwdFiles {
-		_ = file
-		//findByID(uid)
-		//return passwd
+	if pw := findPasswdByID(uid); pw != nil {
+		return pw
 	}
 
 	if !isOemID(uid) {
 		return nil
 	}
 
+	return oemPasswd(uid)
+}
+
+// oemPasswd returns the oem_NNNN passwd entry of uid.
+func oemPasswd(uid uint32) *Passwd {
 	var shell = "/bin/sh"
 	if _, err := os.Stat(shell); err != nil {
 		shell = "/system/bin/sh"
@@ -491,15 +515,19 @@ func oemIDToPasswd(uid uint32) *Passwd {
 }
 
 func oemIDToGroup(gid uint32) *Group {
-	for _, file := range groupFiles {
-		// TODO implement
-		_ = file
+	if group := findGroupByID(gid); group != nil {
+		return group
 	}
 
 	if !isOemID(gid) {
 		return nil
 	}
 
+	return oemGroup(gid)
+}
+
+// oemGroup returns the oem_NNNN group entry of gid.
+func oemGroup(gid uint32) *Group {
 	var group = Group{
 		Name: fmt.Sprintf("oem_%d", gid),
 		GID:  gid,
@@ -523,7 +551,7 @@ func appIDToPasswd(uid uint32) *Passwd {
 	var (
 		dir   = "/data"
 		name  = printAppNameFromUid(uid)
-		appid = uid % AidUserOffset
+		appid = UID(uid).AppID()
 		shell = "/bin/sh"
 	)
 
@@ -563,6 +591,11 @@ func appIDToGroup(gid uint32) *Group {
 }
 
 func Getpwuid(uid uint32) *Passwd {
+	// Reject the uids of users which don't exist.
+	if StrictUsers && !androidUserExists(UID(uid).UserID()) {
+		return nil
+	}
+
 	if info := findAndroidIDInfoByID(uid); info != nil {
 		return androidIInfoToPasswd(*info)
 	}
@@ -581,28 +614,74 @@ func Getpwnam(login string) *Passwd {
 	}
 
 	// Find an entry from the database file
-	for _, file := range passwdFiles {
-		// TODO implement
-		_ = file
+	if pw := findPasswdByName(login); pw != nil {
+		return pw
 	}
 
 	// Handle OEM range.
-	if pw := oemIDToPasswd(oemIDFromName(login)); pw != nil {
-		return pw
+	if id := oemIDFromName(login); id != 0 {
+		if pw := oemIDToPasswd(id); pw != nil {
+			return pw
+		}
 	}
 
 	uid, err := appIDFromName(login, false)
 	if err != nil {
+		// Handle installed package names.
+		if LookupPackageNames {
+			return packageToPasswd(login)
+		}
+		return nil
+	}
+
+	// Reject the uids of users which don't exist.
+	if StrictUsers && !androidUserExists(UID(uid).UserID()) {
 		return nil
 	}
 
 	return appIDToPasswd(uid)
 }
 
-// GetGroupList All users are in just one group, the one passed in.
-func GetGroupList() int {
-	// TODO implement
-	return 0
+var ErrGroupListTruncated = errors.New("group list is truncated")
+
+// GetGroupList stores the gids of the groups user is a member of in groups,
+// like getgrouplist: group comes first, followed by the groups of the
+// partition group files listing user as a member. The platform AIDs have
+// no members, so all users are in just one group there, the one passed in.
+// ngroups is the number of groups of user, if groups is too short to hold
+// them all, the first len(groups) are stored and ErrGroupListTruncated is
+// returned.
+func GetGroupList(user string, group uint32, groups []uint32) (ngroups int, err error) {
+	var (
+		list = []uint32{group}
+		seen = map[uint32]bool{group: true}
+	)
+
+	for _, file := range groupFiles {
+		entries, err := readGroupFile(file[0], file[1])
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if seen[entry.GID] {
+				continue
+			}
+			for _, member := range entry.Members {
+				if member == user {
+					list = append(list, entry.GID)
+					seen[entry.GID] = true
+					break
+				}
+			}
+		}
+	}
+
+	if copy(groups, list) < len(list) {
+		return len(list), ErrGroupListTruncated
+	}
+
+	return len(list), nil
 }
 
 // GetLogin NOLINT: implementing bad function.
@@ -634,14 +713,15 @@ func Getgrnam(name string) *Group {
 	}
 
 	// Find an entry from the database file
-	for _, file := range groupFiles {
-		// TODO implement
-		_ = file
+	if group := findGroupByName(name); group != nil {
+		return group
 	}
 
 	// Handle OEM range.
-	if group := oemIDToGroup(oemIDFromName(name)); group != nil {
-		return group
+	if id := oemIDFromName(name); id != 0 {
+		if group := oemIDToGroup(id); group != nil {
+			return group
+		}
 	}
 
 	gid, err := appIDFromName(name, true)
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -8,3 +9,78 @@ func TestGetgrnam(t *testing.T) {
 	t.Log(Getpwnam("inet"))
 	t.Log(Getpwnam("u0_a48"))
 }
+
+func TestAppIDFromName(t *testing.T) {
+	withProfile(t, Profile{})
+
+	var tests = []struct {
+		name    string
+		isGroup bool
+		id      uint32
+		err     error
+	}{
+		{"u0_a48", false, 10048, nil},
+		{"u10_a48", false, 1010048, nil},
+		{"u2_i1000", false, 291000, nil},
+		{"u1_system", false, 101000, nil},
+		{"u0_a48_cache", true, 20048, nil},
+		{"u0_a48_ext", true, 30048, nil},
+		{"u0_a48_ext_cache", true, 40048, nil},
+		{"all_a48", true, 50048, nil},
+		{"app_48", false, 10048, nil},
+		{"u0_a48_cache", false, 0, ErrUnknownName},
+		{"all_a48", false, 0, ErrUnknownName},
+		{"all_i48", true, 0, ErrUnknownName},
+		{"all_a10000", true, 0, ErrOutOfRange},
+		{"u0_a100000", false, 0, ErrOutOfRange},
+		{"u0_a99999999999", false, 0, ErrOutOfRange},
+		{"app_4294967295", false, 0, ErrOutOfRange},
+		{"u1001_a48", false, 0, ErrUserOverflow},
+		{"u", false, 0, ErrUnknownName},
+		{"u0", false, 0, ErrUnknownName},
+		{"u0_", false, 0, ErrUnknownName},
+		{"u0_a", false, 0, ErrUnknownName},
+		{"u0_a48x", false, 0, ErrUnknownName},
+		{"u0_nobody_at_all", false, 0, ErrUnknownName},
+		{"u١_a48", false, 0, ErrUnknownName},
+		{"u0_a٤٨", false, 0, ErrUnknownName},
+		{"app_", false, 0, ErrUnknownName},
+		{"app_48x", false, 0, ErrUnknownName},
+		{"", false, 0, ErrUnknownName},
+	}
+
+	for _, test := range tests {
+		id, err := appIDFromName(test.name, test.isGroup)
+		if id != test.id || !errors.Is(err, test.err) || (test.err == nil) != (err == nil) {
+			t.Fatalf("appIDFromName(%q, %v) = %d, %v, want %d, %v", test.name, test.isGroup, id, err, test.id, test.err)
+		}
+	}
+}
+
+func FuzzAppNameRoundTrip(f *testing.F) {
+	for _, id := range []uint32{0, AidShell, 10048, 20048, 30048, 40048, 50048, 90000, 1010048, 100099999, 4294967295} {
+		f.Add(id)
+	}
+
+	f.Fuzz(func(t *testing.T, id uint32) {
+		var check = func(name string, isGroup bool) {
+			if name == "" {
+				return
+			}
+
+			got, err := appIDFromName(name, isGroup)
+			if UID(id).UserID() > 1000 {
+				if !errors.Is(err, ErrUserOverflow) {
+					t.Fatalf("appIDFromName(%q, %v) = %d, %v, want ErrUserOverflow", name, isGroup, got, err)
+				}
+				return
+			}
+			if err != nil || got != id {
+				t.Fatalf("appIDFromName(%q, %v) = %d, %v, want %d", name, isGroup, got, err, id)
+			}
+		}
+
+		check(printAppNameFromUid(id), false)
+		check(printAppNameFromGid(id), true)
+	})
+}